@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/conductorone/baton-procore/pkg/client"
 	cfg "github.com/conductorone/baton-procore/pkg/config"
 	"github.com/conductorone/baton-procore/pkg/connector"
 	"github.com/conductorone/baton-sdk/pkg/config"
@@ -46,7 +47,41 @@ func getConnector(ctx context.Context, config *cfg.Procore) (types.ConnectorServ
 		return nil, err
 	}
 
-	cb, err := connector.New(ctx, config.GetString(cfg.ClientId.FieldName), config.GetString(cfg.ClientSecret.FieldName))
+	// explicit URLs win over the environment preset, so a mock server only needs the URLs it stands in for
+	environment, ok := client.LookupEnvironment(config.GetString(cfg.Environment.FieldName))
+	if !ok {
+		return nil, fmt.Errorf("unknown environment %q", config.GetString(cfg.Environment.FieldName))
+	}
+	if baseURL := config.GetString(cfg.BaseURL.FieldName); baseURL != "" {
+		environment.BaseURL = baseURL
+	}
+	if tokenURL := config.GetString(cfg.TokenURL.FieldName); tokenURL != "" {
+		environment.TokenURL = tokenURL
+	}
+
+	cb, err := connector.New(
+		ctx,
+		config.GetString(cfg.ClientId.FieldName),
+		config.GetString(cfg.ClientSecret.FieldName),
+		connector.Options{
+			DefaultCompanyPermissionTemplate: config.GetString(cfg.DefaultCompanyPermissionTemplate.FieldName),
+			RemoveFromProjectsOnDelete:       config.GetBool(cfg.RemoveFromProjectsOnDelete.FieldName),
+			IncludeCompanyIds:                config.GetStringSlice(cfg.IncludeCompanyIds.FieldName),
+			ExcludeCompanyIds:                config.GetStringSlice(cfg.ExcludeCompanyIds.FieldName),
+			ProjectFilter: connector.ProjectFilter{
+				ActiveOnly:       config.GetBool(cfg.ActiveProjectsOnly.FieldName),
+				SkipDemo:         config.GetBool(cfg.SkipDemoProjects.FieldName),
+				IncludeStages:    config.GetStringSlice(cfg.IncludeProjectStages.FieldName),
+				ExcludeStages:    config.GetStringSlice(cfg.ExcludeProjectStages.FieldName),
+				IncludeTypes:     config.GetStringSlice(cfg.IncludeProjectTypes.FieldName),
+				ExcludeTypes:     config.GetStringSlice(cfg.ExcludeProjectTypes.FieldName),
+				IncludeRegionIds: config.GetStringSlice(cfg.IncludeProjectRegionIds.FieldName),
+				ExcludeRegionIds: config.GetStringSlice(cfg.ExcludeProjectRegionIds.FieldName),
+			},
+			RetryMaxAttempts: config.GetInt(cfg.RetryMaxAttempts.FieldName),
+			Environment:      environment,
+		},
+	)
 	if err != nil {
 		l.Error("error creating connector", zap.Error(err))
 		return nil, err
@@ -1,16 +1,66 @@
 package client
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"net/http"
+	"strings"
 
 	"github.com/conductorone/baton-sdk/pkg/uhttp"
+	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
+	"go.uber.org/zap"
 	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/clientcredentials"
 )
 
 type Client struct {
 	*uhttp.BaseHttpClient
+	tokenSource oauth2.TokenSource
+	// throttle is shared by every builder using the client, as they all draw from the same rate limit.
+	throttle *throttle
+	// maxAttempts is how many times a retryable request is sent before its error is returned.
+	maxAttempts int
+	// baseURL is the REST root every path in urls.go is joined to.
+	baseURL  string
+	tokenURL string
+	// uncached sends GETs past the uhttp cache, see Uncached.
+	uncached bool
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithMaxAttempts sets how many times idempotent requests are attempted on transient failures.
+// Values below 1 keep the default.
+func WithMaxAttempts(maxAttempts int) Option {
+	return func(c *Client) {
+		if maxAttempts > 0 {
+			c.maxAttempts = maxAttempts
+		}
+	}
+}
+
+// WithBaseURL points the client at another REST root, such as the sandbox or a local mock server.
+// An empty value keeps the production URL.
+func WithBaseURL(baseURL string) Option {
+	return func(c *Client) {
+		if baseURL != "" {
+			c.baseURL = strings.TrimRight(baseURL, "/")
+		}
+	}
+}
+
+// WithTokenURL sets the OAuth token endpoint used for the client credentials grant.
+// An empty value keeps the production URL.
+func WithTokenURL(tokenURL string) Option {
+	return func(c *Client) {
+		if tokenURL != "" {
+			c.tokenURL = tokenURL
+		}
+	}
 }
 
 //lint:ignore U1000 Ignore unused function for debugging
@@ -20,19 +70,157 @@ func (c *Client) _Token() *oauth2.Token {
 	return tok
 }
 
-func New(ctx context.Context, clientId, clientSecret string) (*Client, error) {
+func New(ctx context.Context, clientId, clientSecret string, opts ...Option) (*Client, error) {
+	rv := &Client{
+		throttle:    &throttle{},
+		maxAttempts: DefaultMaxAttempts,
+		baseURL:     ProductionBaseURL,
+		tokenURL:    ProductionTokenURL,
+	}
+	for _, opt := range opts {
+		opt(rv)
+	}
+
 	config := &clientcredentials.Config{
 		ClientID:     clientId,
 		ClientSecret: clientSecret,
-		TokenURL:     "https://login.procore.com/oauth/token",
+		TokenURL:     rv.tokenURL,
 	}
 
-	client, err := uhttp.NewBaseHttpClientWithContext(ctx, config.Client(ctx))
+	rv.tokenSource = config.TokenSource(ctx)
+	// the throttle sits below uhttp, so only requests that reach Procore draw from the budget
+	base := http.DefaultTransport
+	if hc, ok := ctx.Value(oauth2.HTTPClient).(*http.Client); ok && hc.Transport != nil {
+		base = hc.Transport
+	}
+	httpClient := &http.Client{
+		Transport: &oauth2.Transport{
+			Source: rv.tokenSource,
+			Base:   &throttledTransport{base: base, throttle: rv.throttle},
+		},
+	}
+	client, err := uhttp.NewBaseHttpClientWithContext(ctx, httpClient)
 	if err != nil {
 		return nil, fmt.Errorf("error creating HTTP client: %w", err)
 	}
+	rv.BaseHttpClient = client
+	return rv, nil
+}
+
+// Uncached returns a view of the client whose reads skip the HTTP cache. uhttp keeps GET responses
+// for the whole sync, so provisioning must use it for every read it decides a write on: a grant
+// followed by a revoke would otherwise see the state from before the grant.
+func (c *Client) Uncached() *Client {
+	rv := *c
+	rv.uncached = true
+	return &rv
+}
+
+// url joins one of the paths in urls.go to the configured base URL.
+func (c *Client) url(path string, args ...any) string {
+	return c.baseURL + fmt.Sprintf(path, args...)
+}
+
+// do sends the request and turns every failure into an *APIError. GETs are retried on transient failures.
+func (c *Client) do(req *http.Request, options ...uhttp.DoOption) (*http.Response, error) {
+	return c.send(req, req.Method == http.MethodGet, options...)
+}
+
+// doIdempotent is do for writes that can safely be sent twice, such as the project add and remove actions.
+func (c *Client) doIdempotent(req *http.Request, options ...uhttp.DoOption) (*http.Response, error) {
+	return c.send(req, true, options...)
+}
 
-	return &Client{
-		client,
-	}, nil
+func (c *Client) send(req *http.Request, retry bool, options ...uhttp.DoOption) (*http.Response, error) {
+	ctx := req.Context()
+	l := ctxzap.Extract(ctx)
+	attempts := 1
+	if retry {
+		attempts = c.maxAttempts
+	}
+
+	for attempt := 1; ; attempt++ {
+		attemptReq, err := rewind(req, attempt)
+		if err != nil {
+			return nil, err
+		}
+
+		res, err := c.attempt(attemptReq, options...)
+		if err == nil {
+			return res, nil
+		}
+		apiErr := newAPIError(req, res, err)
+
+		if attempt >= attempts || !isRetryable(res, err) {
+			if res != nil && res.StatusCode == http.StatusTooManyRequests {
+				// the throttle holds later requests until the reset, this one fails right away
+				l.Warn("baton-procore: Procore rate limit exceeded", zap.String("path", req.URL.Path))
+			}
+			return nil, apiErr
+		}
+
+		// a 429 also holds the throttle until the reset, which the next attempt waits for
+		delay := max(backoff(attempt), retryAfter(res))
+		l.Warn("baton-procore: retrying Procore request",
+			zap.String("method", req.Method),
+			zap.String("path", req.URL.Path),
+			zap.Int("attempt", attempt),
+			zap.Duration("delay", delay),
+			zap.Error(apiErr),
+		)
+		err = sleep(ctx, delay)
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// attempt sends the request once, through the HTTP cache unless the client is uncached.
+func (c *Client) attempt(req *http.Request, options ...uhttp.DoOption) (*http.Response, error) {
+	if c.uncached {
+		return c.doUncached(req, options...)
+	}
+	return c.Do(req, options...)
+}
+
+// doUncached is uhttp's Do without the cache: the body is buffered so errors can be read from it,
+// and the options only run on 2xx responses.
+func (c *Client) doUncached(req *http.Request, options ...uhttp.DoOption) (*http.Response, error) {
+	res, err := c.HttpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	body, err := io.ReadAll(res.Body)
+	res.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return res, err
+	}
+	if res.StatusCode < http.StatusOK || res.StatusCode >= http.StatusMultipleChoices {
+		return res, fmt.Errorf("unexpected status code: %d", res.StatusCode)
+	}
+
+	wrapper := &uhttp.WrapperResponse{
+		Header:     res.Header,
+		Status:     res.Status,
+		StatusCode: res.StatusCode,
+		Body:       body,
+	}
+	var errs []error
+	for _, option := range options {
+		if err := option(wrapper); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return res, errors.Join(errs...)
+}
+
+// CheckToken fetches an access token with the client credentials. The token is
+// reused by later requests, so this only costs a round trip when none is cached.
+func (c *Client) CheckToken() error {
+	_, err := c.tokenSource.Token()
+	if err != nil {
+		return fmt.Errorf("error getting access token: %w", err)
+	}
+	return nil
 }
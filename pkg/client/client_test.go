@@ -0,0 +1,83 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// newTestClient returns a Client sending every request, including the token request, to the handler.
+func newTestClient(t *testing.T, mux *http.ServeMux, opts ...Option) *Client {
+	t.Helper()
+	mux.HandleFunc("POST /oauth/token", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"access_token":"token","token_type":"bearer","expires_in":3600}`))
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	opts = append([]Option{WithBaseURL(srv.URL), WithTokenURL(srv.URL + "/oauth/token")}, opts...)
+	c, err := New(context.Background(), "id", "secret", opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestCacheHitsDoNotUseTheBudget(t *testing.T) {
+	ctx := context.Background()
+	reset := strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10)
+
+	var calls atomic.Int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1.0/companies", func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set(rateLimitLimitHeader, "100")
+		w.Header().Set(rateLimitRemainingHeader, "50")
+		w.Header().Set(rateLimitResetHeader, reset)
+		_, _ = w.Write([]byte(`[]`))
+	})
+	c := newTestClient(t, mux)
+
+	for range 3 {
+		if _, _, _, err := c.GetCompanies(ctx, 1); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if calls.Load() != 1 {
+		t.Fatalf("Procore was called %d times, want 1", calls.Load())
+	}
+	if c.throttle.remaining != 50 {
+		t.Fatalf("remaining budget = %d, want 50 as the cache hits never reached Procore", c.throttle.remaining)
+	}
+}
+
+func TestRateLimitedFinalAttemptReturnsImmediately(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1.0/companies", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(retryAfterHeader, "3600")
+		w.WriteHeader(http.StatusTooManyRequests)
+	})
+	c := newTestClient(t, mux, WithMaxAttempts(1))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, _, _, err := c.GetCompanies(ctx, 1)
+	if status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("error = %v, want the rate limit error", err)
+	}
+	if ctx.Err() != nil {
+		t.Fatal("the error was only returned after waiting for the rate limit reset")
+	}
+	if c.throttle.delay(time.Now()) < time.Hour-time.Minute {
+		t.Fatal("the throttle does not hold the next request until the reset")
+	}
+}
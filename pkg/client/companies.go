@@ -12,7 +12,7 @@ import (
 const perPage = 100
 
 func (c *Client) GetCompanies(ctx context.Context, page int) ([]Company, *http.Response, *v2.RateLimitDescription, error) {
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, GetCompaniesURL, nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url(GetCompaniesPath), nil)
 	if err != nil {
 		return nil, nil, nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -24,20 +24,14 @@ func (c *Client) GetCompanies(ctx context.Context, page int) ([]Company, *http.R
 
 	var target []Company
 	var rateLimitData v2.RateLimitDescription
-	res, err := c.Do(req,
+	res, err := c.do(req,
 		uhttp.WithJSONResponse(&target),
-		uhttp.WithRatelimitData(&rateLimitData),
+		withRateLimitData(&rateLimitData),
 	)
 	if err != nil {
-		logBody(ctx, res.Body)
 		return nil, nil, nil, fmt.Errorf("baton-procore: error getting companies: %w", err)
 	}
 
 	defer res.Body.Close()
-	if res.StatusCode < http.StatusOK || res.StatusCode >= http.StatusMultipleChoices {
-		logBody(ctx, res.Body)
-		return nil, nil, nil, fmt.Errorf("unexpected status code: %d, expected: %d", res.StatusCode, http.StatusOK)
-	}
-
 	return target, res, &rateLimitData, nil
 }
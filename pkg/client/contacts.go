@@ -0,0 +1,41 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/uhttp"
+)
+
+// GetCompanyContacts lists the people of the company directory that have no Procore login,
+// previously known as reference users.
+// https://developers.procore.com/reference/rest/company-people?version=latest#list-company-people
+func (c *Client) GetCompanyContacts(ctx context.Context, companyId string, page int) ([]Contact, *http.Response, *v2.RateLimitDescription, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url(CompanyPeoplePath, companyId), nil)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Procore-Company-Id", companyId)
+
+	values := req.URL.Query()
+	values.Set("filters[reference_users_only]", "true")
+	values.Set("page", fmt.Sprintf("%d", page))
+	values.Set("per_page", fmt.Sprintf("%d", perPage))
+	req.URL.RawQuery = values.Encode()
+
+	var target []Contact
+	var rateLimitData v2.RateLimitDescription
+	res, err := c.do(req,
+		uhttp.WithJSONResponse(&target),
+		withRateLimitData(&rateLimitData),
+	)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("error getting company contacts from Procore API: %w", err)
+	}
+
+	defer res.Body.Close()
+	return target, res, &rateLimitData, nil
+}
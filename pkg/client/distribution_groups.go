@@ -0,0 +1,101 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/uhttp"
+)
+
+// https://developers.procore.com/reference/rest/distribution-groups?version=latest#list-distribution-groups
+func (c *Client) GetDistributionGroups(ctx context.Context, companyId, projectId string, page int) ([]DistributionGroup, *http.Response, *v2.RateLimitDescription, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url(DistributionGroupsPath, projectId), nil)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Procore-Company-Id", companyId)
+
+	values := req.URL.Query()
+	values.Set("page", fmt.Sprintf("%d", page))
+	values.Set("per_page", fmt.Sprintf("%d", perPage))
+	req.URL.RawQuery = values.Encode()
+
+	var target []DistributionGroup
+	var rateLimitData v2.RateLimitDescription
+	res, err := c.do(req,
+		uhttp.WithJSONResponse(&target),
+		withRateLimitData(&rateLimitData),
+	)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("error getting distribution groups from Procore API: %w", err)
+	}
+
+	defer res.Body.Close()
+	return target, res, &rateLimitData, nil
+}
+
+func (c *Client) GetDistributionGroupUsers(ctx context.Context, companyId, projectId string, groupId, page int) ([]User, *http.Response, *v2.RateLimitDescription, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url(DistributionGroupUsersPath, projectId, groupId), nil)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Procore-Company-Id", companyId)
+
+	values := req.URL.Query()
+	values.Set("page", fmt.Sprintf("%d", page))
+	values.Set("per_page", fmt.Sprintf("%d", perPage))
+	req.URL.RawQuery = values.Encode()
+
+	var target []User
+	var rateLimitData v2.RateLimitDescription
+	res, err := c.do(req,
+		uhttp.WithJSONResponse(&target),
+		withRateLimitData(&rateLimitData),
+	)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("error getting distribution group users from Procore API: %w", err)
+	}
+
+	defer res.Body.Close()
+	return target, res, &rateLimitData, nil
+}
+
+// https://developers.procore.com/reference/rest/distribution-groups?version=latest#add-user-to-distribution-group
+func (c *Client) AddUserToDistributionGroup(ctx context.Context, companyId, projectId string, groupId, userId int) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url(AddUserToDistributionGroupPath, projectId, groupId, userId), nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Procore-Company-Id", companyId)
+
+	res, err := c.do(req)
+	if err != nil {
+		return fmt.Errorf("baton-procore: error adding user to distribution group: %w", err)
+	}
+
+	defer res.Body.Close()
+	return nil
+}
+
+// https://developers.procore.com/reference/rest/distribution-groups?version=latest#remove-user-from-distribution-group
+func (c *Client) RemoveUserFromDistributionGroup(ctx context.Context, companyId, projectId string, groupId, userId int) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, c.url(RemoveUserFromDistributionGroupPath, projectId, groupId, userId), nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Procore-Company-Id", companyId)
+
+	res, err := c.do(req)
+	if err != nil {
+		return fmt.Errorf("baton-procore: error removing user from distribution group: %w", err)
+	}
+
+	defer res.Body.Close()
+	return nil
+}
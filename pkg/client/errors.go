@@ -0,0 +1,151 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"golang.org/x/oauth2"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// maxErrorMessageLength caps the Procore error message kept in an APIError.
+const maxErrorMessageLength = 512
+
+// APIError is returned by every Client call that Procore, or the token endpoint, did not answer
+// with a 2xx status. StatusCode is 0 when no response was received at all.
+type APIError struct {
+	StatusCode int
+	// Message is the error reported by Procore in the response body.
+	Message string
+	Method  string
+	Path    string
+	err     error
+}
+
+func (e *APIError) Error() string {
+	if e.StatusCode == 0 {
+		return fmt.Sprintf("procore API %s %s: %s", e.Method, e.Path, e.Message)
+	}
+	return fmt.Sprintf("procore API %s %s: status %d: %s", e.Method, e.Path, e.StatusCode, e.Message)
+}
+
+func (e *APIError) Unwrap() error {
+	return e.err
+}
+
+// Code maps the error to the gRPC code the baton runtime uses to decide whether to retry.
+func (e *APIError) Code() codes.Code {
+	switch {
+	case e.StatusCode == 0:
+		return codes.Unavailable
+	case e.StatusCode == http.StatusBadRequest, e.StatusCode == http.StatusUnprocessableEntity:
+		return codes.InvalidArgument
+	case e.StatusCode == http.StatusUnauthorized:
+		return codes.Unauthenticated
+	case e.StatusCode == http.StatusForbidden:
+		return codes.PermissionDenied
+	case e.StatusCode == http.StatusNotFound:
+		return codes.NotFound
+	case e.StatusCode == http.StatusRequestTimeout:
+		return codes.DeadlineExceeded
+	case e.StatusCode == http.StatusConflict:
+		return codes.AlreadyExists
+	case e.StatusCode == http.StatusTooManyRequests:
+		return codes.ResourceExhausted
+	case e.StatusCode >= http.StatusInternalServerError:
+		return codes.Unavailable
+	default:
+		return codes.Unknown
+	}
+}
+
+// GRPCStatus keeps the details uhttp attached to the error, such as the rate limit of a 429.
+func (e *APIError) GRPCStatus() *status.Status {
+	st := status.New(e.Code(), e.Error()).Proto()
+	if wrapped, ok := status.FromError(e.err); ok {
+		st.Details = wrapped.Proto().GetDetails()
+	}
+	return status.FromProto(st)
+}
+
+func newAPIError(req *http.Request, res *http.Response, err error) error {
+	// a cancelled sync is not a Procore failure
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return err
+	}
+
+	apiErr := &APIError{
+		Method: req.Method,
+		Path:   req.URL.Path,
+		err:    err,
+	}
+
+	if res == nil {
+		var retrieveErr *oauth2.RetrieveError
+		if errors.As(err, &retrieveErr) && retrieveErr.Response != nil {
+			// the token endpoint rejected the client credentials
+			apiErr.StatusCode = retrieveErr.Response.StatusCode
+			if apiErr.StatusCode == http.StatusBadRequest {
+				apiErr.StatusCode = http.StatusUnauthorized
+			}
+			apiErr.Message = errorMessage(retrieveErr.Body)
+			if apiErr.Message == "" {
+				apiErr.Message = retrieveErr.Error()
+			}
+			return apiErr
+		}
+		apiErr.Message = err.Error()
+		return apiErr
+	}
+
+	apiErr.StatusCode = res.StatusCode
+	if res.StatusCode >= http.StatusOK && res.StatusCode < http.StatusMultipleChoices {
+		// a 2xx whose body could not be decoded
+		apiErr.Message = err.Error()
+		return apiErr
+	}
+	if res.Body != nil {
+		body, _ := io.ReadAll(res.Body)
+		apiErr.Message = errorMessage(body)
+	}
+	if apiErr.Message == "" {
+		apiErr.Message = err.Error()
+	}
+	return apiErr
+}
+
+// errorMessage extracts the error from a Procore response body. Procore reports errors in an
+// "errors", "error" or "message" field, either as a string or as a map of field errors.
+func errorMessage(body []byte) string {
+	var payload map[string]any
+	if json.Unmarshal(body, &payload) != nil {
+		return truncate(strings.TrimSpace(string(body)))
+	}
+	for _, key := range []string{"errors", "error", "message", "error_description"} {
+		value, ok := payload[key]
+		if !ok || value == nil {
+			continue
+		}
+		if s, ok := value.(string); ok {
+			return truncate(s)
+		}
+		raw, err := json.Marshal(value)
+		if err == nil {
+			return truncate(string(raw))
+		}
+	}
+	return truncate(strings.TrimSpace(string(body)))
+}
+
+func truncate(s string) string {
+	if len(s) <= maxErrorMessageLength {
+		return s
+	}
+	return s[:maxErrorMessageLength] + "..."
+}
@@ -62,6 +62,11 @@ type Project struct {
 	Zip                     *string                `json:"zip"`
 }
 
+// ProjectFilters are the project list filters Procore applies server side.
+type ProjectFilters struct {
+	ActiveOnly bool
+}
+
 type ProjectStage struct {
 	Id   int64  `json:"id"`
 	Name string `json:"name"`
@@ -168,6 +173,42 @@ type UserBody struct {
 	JobTitle   string `json:"job_title,omitempty"`
 	IsEmployee bool   `json:"is_employee,omitempty"`
 	IsActive   bool   `json:"is_active,omitempty"`
+
+	CompanyPermissionTemplateId *int `json:"company_permission_template_id,omitempty"`
+	VendorId                    *int `json:"vendor_id,omitempty"`
+}
+
+type UpdateUserBody struct {
+	User UserUpdate `json:"user"`
+}
+
+// UserUpdate only carries the fields that should change, so every field is optional.
+type UserUpdate struct {
+	CompanyPermissionTemplateId *int    `json:"company_permission_template_id,omitempty"`
+	IsActive                    *bool   `json:"is_active,omitempty"`
+	FirstName                   *string `json:"first_name,omitempty"`
+	LastName                    *string `json:"last_name,omitempty"`
+	JobTitle                    *string `json:"job_title,omitempty"`
+	BusinessPhone               *string `json:"business_phone,omitempty"`
+	MobilePhone                 *string `json:"mobile_phone,omitempty"`
+	EmployeeId                  *string `json:"employee_id,omitempty"`
+	Address                     *string `json:"address,omitempty"`
+	City                        *string `json:"city,omitempty"`
+	StateCode                   *string `json:"state_code,omitempty"`
+	Zip                         *string `json:"zip,omitempty"`
+	CountryCode                 *string `json:"country_code,omitempty"`
+}
+
+type AddProjectUserBody struct {
+	PermissionTemplateId *int `json:"permission_template_id,omitempty"`
+}
+
+type UpdateProjectUserBody struct {
+	User ProjectUserUpdate `json:"user"`
+}
+
+type ProjectUserUpdate struct {
+	PermissionTemplateId *int `json:"permission_template_id,omitempty"`
 }
 
 type PermissionTemplate struct {
@@ -178,6 +219,32 @@ type PermissionTemplate struct {
 }
 
 type Vendor struct {
+	Id           int    `json:"id"`
+	Name         string `json:"name"`
+	Abbreviation string `json:"abbreviated_name"`
+	IsActive     bool   `json:"is_active"`
+}
+
+type DistributionGroup struct {
 	Id   int    `json:"id"`
 	Name string `json:"name"`
 }
+
+// ProjectRole is a single role slot on a project, such as "Project Manager", and the user filling it.
+type ProjectRole struct {
+	Id        int    `json:"id"`
+	Role      string `json:"role"`
+	Name      string `json:"name"`
+	UserId    *int   `json:"user_id"`
+	ContactId *int   `json:"contact_id"`
+}
+
+type UpdateProjectRoleBody struct {
+	ProjectId   string            `json:"project_id"`
+	ProjectRole ProjectRoleUpdate `json:"project_role"`
+}
+
+type ProjectRoleUpdate struct {
+	// UserId is sent as null to clear the role.
+	UserId *int `json:"user_id"`
+}
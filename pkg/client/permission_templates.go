@@ -0,0 +1,66 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/uhttp"
+)
+
+// https://developers.procore.com/reference/rest/company-permission-templates?version=latest#list-company-permission-templates
+func (c *Client) GetCompanyPermissionTemplates(ctx context.Context, companyId string, page int) ([]PermissionTemplate, *http.Response, *v2.RateLimitDescription, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url(CompanyPermissionTemplatesPath, companyId), nil)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Procore-Company-Id", companyId)
+
+	values := req.URL.Query()
+	values.Set("page", fmt.Sprintf("%d", page))
+	values.Set("per_page", fmt.Sprintf("%d", perPage))
+	req.URL.RawQuery = values.Encode()
+
+	var target []PermissionTemplate
+	var rateLimitData v2.RateLimitDescription
+	res, err := c.do(req,
+		uhttp.WithJSONResponse(&target),
+		withRateLimitData(&rateLimitData),
+	)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("error getting company permission templates from Procore API: %w", err)
+	}
+
+	defer res.Body.Close()
+	return target, res, &rateLimitData, nil
+}
+
+// https://developers.procore.com/reference/rest/project-permission-templates?version=latest#list-project-permission-templates
+func (c *Client) GetProjectPermissionTemplates(ctx context.Context, companyId, projectId string, page int) ([]PermissionTemplate, *http.Response, *v2.RateLimitDescription, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url(ProjectPermissionTemplatesPath, projectId), nil)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Procore-Company-Id", companyId)
+
+	values := req.URL.Query()
+	values.Set("page", fmt.Sprintf("%d", page))
+	values.Set("per_page", fmt.Sprintf("%d", perPage))
+	req.URL.RawQuery = values.Encode()
+
+	var target []PermissionTemplate
+	var rateLimitData v2.RateLimitDescription
+	res, err := c.do(req,
+		uhttp.WithJSONResponse(&target),
+		withRateLimitData(&rateLimitData),
+	)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("error getting project permission templates from Procore API: %w", err)
+	}
+
+	defer res.Body.Close()
+	return target, res, &rateLimitData, nil
+}
@@ -0,0 +1,68 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/uhttp"
+)
+
+// https://developers.procore.com/reference/rest/project-roles?version=latest#list-project-roles
+func (c *Client) GetProjectRoles(ctx context.Context, companyId, projectId string, page int) ([]ProjectRole, *http.Response, *v2.RateLimitDescription, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url(ProjectRolesPath), nil)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Procore-Company-Id", companyId)
+
+	values := req.URL.Query()
+	values.Set("project_id", projectId)
+	values.Set("page", fmt.Sprintf("%d", page))
+	values.Set("per_page", fmt.Sprintf("%d", perPage))
+	req.URL.RawQuery = values.Encode()
+
+	var target []ProjectRole
+	var rateLimitData v2.RateLimitDescription
+	res, err := c.do(req,
+		uhttp.WithJSONResponse(&target),
+		withRateLimitData(&rateLimitData),
+	)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("error getting project roles from Procore API: %w", err)
+	}
+
+	defer res.Body.Close()
+	return target, res, &rateLimitData, nil
+}
+
+// SetProjectRoleUser assigns the user to the project role, or clears the role when userId is nil.
+// https://developers.procore.com/reference/rest/project-roles?version=latest#update-project-role
+func (c *Client) SetProjectRoleUser(ctx context.Context, companyId, projectId string, roleId int, userId *int) error {
+	jsonBody, err := json.Marshal(UpdateProjectRoleBody{
+		ProjectId:   projectId,
+		ProjectRole: ProjectRoleUpdate{UserId: userId},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal project role: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPatch, c.url(ProjectRolePath, roleId), bytes.NewReader(jsonBody))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Procore-Company-Id", companyId)
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := c.do(req)
+	if err != nil {
+		return fmt.Errorf("baton-procore: error updating project role: %w", err)
+	}
+
+	defer res.Body.Close()
+	return nil
+}
@@ -1,7 +1,9 @@
 package client
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
 
@@ -10,7 +12,13 @@ import (
 )
 
 func (c *Client) GetProjects(ctx context.Context, companyId string, page int) ([]Project, *http.Response, *v2.RateLimitDescription, error) {
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, GetProjectsURL, nil)
+	return c.GetFilteredProjects(ctx, companyId, page, ProjectFilters{})
+}
+
+// GetFilteredProjects lists the projects of the company, leaving out the ones the filters exclude server side.
+// https://developers.procore.com/reference/rest/projects?version=latest#list-projects
+func (c *Client) GetFilteredProjects(ctx context.Context, companyId string, page int, filters ProjectFilters) ([]Project, *http.Response, *v2.RateLimitDescription, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url(GetProjectsPath), nil)
 	if err != nil {
 		return nil, nil, nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -18,71 +26,141 @@ func (c *Client) GetProjects(ctx context.Context, companyId string, page int) ([
 	req.Header.Set("Procore-Company-Id", companyId)
 	values := req.URL.Query()
 	values.Set("company_id", companyId)
+	if filters.ActiveOnly {
+		values.Set("filters[by_status]", "Active")
+	}
 	values.Set("page", fmt.Sprintf("%d", page))
 	values.Set("per_page", fmt.Sprintf("%d", perPage))
 	req.URL.RawQuery = values.Encode()
 
 	var target []Project
 	var rateLimitData v2.RateLimitDescription
-	res, err := c.Do(req,
+	res, err := c.do(req,
 		uhttp.WithJSONResponse(&target),
-		uhttp.WithRatelimitData(&rateLimitData),
+		withRateLimitData(&rateLimitData),
 	)
 	if err != nil {
-		logBody(ctx, res.Body)
 		return nil, nil, nil, fmt.Errorf("baton-procore: error getting projects: %w", err)
 	}
 
 	defer res.Body.Close()
-	if res.StatusCode < http.StatusOK || res.StatusCode >= http.StatusMultipleChoices {
-		logBody(ctx, res.Body)
-		return nil, nil, nil, fmt.Errorf("unexpected status code: %d, expected: %d", res.StatusCode, http.StatusOK)
+	return target, res, &rateLimitData, nil
+}
+
+// GetCompanyUserProjects lists the projects of the company the user belongs to.
+func (c *Client) GetCompanyUserProjects(ctx context.Context, companyId string, userId, page int) ([]Project, *http.Response, *v2.RateLimitDescription, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url(CompanyUserProjectsPath, companyId, userId), nil)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to create request: %w", err)
 	}
+
+	req.Header.Set("Procore-Company-Id", companyId)
+	values := req.URL.Query()
+	values.Set("page", fmt.Sprintf("%d", page))
+	values.Set("per_page", fmt.Sprintf("%d", perPage))
+	req.URL.RawQuery = values.Encode()
+
+	var target []Project
+	var rateLimitData v2.RateLimitDescription
+	res, err := c.do(req,
+		uhttp.WithJSONResponse(&target),
+		withRateLimitData(&rateLimitData),
+	)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("baton-procore: error getting company user projects: %w", err)
+	}
+
+	defer res.Body.Close()
 	return target, res, &rateLimitData, nil
 }
 
+// AddUserToProject adds a company user to the project directory. When permissionTemplateId is nil,
+// Procore picks the project's default permission template.
 // https://developers.procore.com/reference/rest/project-users?version=latest#add-company-user-to-project
-func (c *Client) AddUserToProject(ctx context.Context, companyId, projectId string, userId int) error {
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf(AddUserToProjectURL, projectId, userId), nil)
+func (c *Client) AddUserToProject(ctx context.Context, companyId, projectId string, userId int, permissionTemplateId *int) error {
+	jsonBody, err := json.Marshal(AddProjectUserBody{PermissionTemplateId: permissionTemplateId})
+	if err != nil {
+		return fmt.Errorf("failed to marshal project user: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url(AddUserToProjectPath, projectId, userId), bytes.NewReader(jsonBody))
 	if err != nil {
 		return fmt.Errorf("failed to create request: %w", err)
 	}
 
 	req.Header.Set("Procore-Company-Id", companyId)
+	req.Header.Set("Content-Type", "application/json")
 
-	res, err := c.Do(req)
+	res, err := c.doIdempotent(req)
 	if err != nil {
-		logBody(ctx, res.Body)
 		return fmt.Errorf("baton-procore: error adding user to project: %w", err)
 	}
 
 	defer res.Body.Close()
-	if res.StatusCode < http.StatusOK || res.StatusCode >= http.StatusMultipleChoices {
-		logBody(ctx, res.Body)
-		return fmt.Errorf("unexpected status code: %d, expected: %d", res.StatusCode, http.StatusNoContent)
-	}
 	return nil
 }
 
 // https://developers.procore.com/reference/rest/project-users?version=latest#remove-a-user-from-the-project
 func (c *Client) RemoveUserFromProject(ctx context.Context, companyId, projectId string, userId int) error {
-	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, fmt.Sprintf(RemoveUserFromProjectURL, projectId, userId), nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, c.url(RemoveUserFromProjectPath, projectId, userId), nil)
 	if err != nil {
 		return fmt.Errorf("failed to create request: %w", err)
 	}
 
 	req.Header.Set("Procore-Company-Id", companyId)
 
-	res, err := c.Do(req)
+	res, err := c.doIdempotent(req)
 	if err != nil {
-		logBody(ctx, res.Body)
 		return fmt.Errorf("baton-procore: error removing user from project: %w", err)
 	}
 
 	defer res.Body.Close()
-	if res.StatusCode < http.StatusOK || res.StatusCode >= http.StatusMultipleChoices {
-		logBody(ctx, res.Body)
-		return fmt.Errorf("unexpected status code: %d, expected: %d", res.StatusCode, http.StatusNoContent)
+	return nil
+}
+
+// https://developers.procore.com/reference/rest/project-users?version=latest#show-project-user
+func (c *Client) GetProjectUser(ctx context.Context, companyId, projectId string, userId int) (*User, *v2.RateLimitDescription, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url(ProjectUserPath, projectId, userId), nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Procore-Company-Id", companyId)
+
+	var target User
+	var rateLimitData v2.RateLimitDescription
+	res, err := c.do(req,
+		uhttp.WithJSONResponse(&target),
+		withRateLimitData(&rateLimitData),
+	)
+	if err != nil {
+		return nil, nil, fmt.Errorf("baton-procore: error getting project user: %w", err)
+	}
+
+	defer res.Body.Close()
+	return &target, &rateLimitData, nil
+}
+
+// https://developers.procore.com/reference/rest/project-users?version=latest#update-project-user
+func (c *Client) UpdateProjectUserPermissionTemplate(ctx context.Context, companyId, projectId string, userId, permissionTemplateId int) error {
+	jsonBody, err := json.Marshal(UpdateProjectUserBody{
+		User: ProjectUserUpdate{PermissionTemplateId: &permissionTemplateId},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal project user: %w", err)
 	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPatch, c.url(ProjectUserPath, projectId, userId), bytes.NewReader(jsonBody))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Procore-Company-Id", companyId)
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := c.do(req)
+	if err != nil {
+		return fmt.Errorf("baton-procore: error updating project user: %w", err)
+	}
+
+	defer res.Body.Close()
 	return nil
 }
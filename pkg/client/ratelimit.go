@@ -0,0 +1,190 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/uhttp"
+	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Procore reports the hourly request budget of the app on every response.
+// https://developers.procore.com/documentation/rate-limiting
+const (
+	rateLimitLimitHeader     = "X-Rate-Limit-Limit"
+	rateLimitRemainingHeader = "X-Rate-Limit-Remaining"
+	rateLimitResetHeader     = "X-Rate-Limit-Reset"
+	retryAfterHeader         = "Retry-After"
+
+	// throttleThreshold is the share of the budget, in percent, below which requests get spaced out.
+	throttleThreshold = 20
+	// defaultRateLimitWait is used on a 429 that carries no reset time.
+	defaultRateLimitWait = time.Minute
+)
+
+// parseRateLimit reads the Procore rate limit headers. It reports false when the response has none.
+func parseRateLimit(statusCode int, header http.Header) (*v2.RateLimitDescription, bool) {
+	limitStr := header.Get(rateLimitLimitHeader)
+	remainingStr := header.Get(rateLimitRemainingHeader)
+	resetStr := header.Get(rateLimitResetHeader)
+	if limitStr == "" && remainingStr == "" && resetStr == "" && statusCode != http.StatusTooManyRequests {
+		return nil, false
+	}
+
+	limit, _ := strconv.ParseInt(limitStr, 10, 64)
+	remaining, _ := strconv.ParseInt(remainingStr, 10, 64)
+
+	var resetAt time.Time
+	// the reset is a unix timestamp in seconds
+	if reset, err := strconv.ParseInt(resetStr, 10, 64); err == nil {
+		resetAt = time.Unix(reset, 0)
+	}
+
+	rateLimitStatus := v2.RateLimitDescription_STATUS_OK
+	if statusCode == http.StatusTooManyRequests {
+		rateLimitStatus = v2.RateLimitDescription_STATUS_OVERLIMIT
+		remaining = 0
+		now := time.Now()
+		if retryAfter, ok := parseRetryAfter(header.Get(retryAfterHeader), now); ok {
+			resetAt = now.Add(retryAfter)
+		}
+		if resetAt.IsZero() {
+			resetAt = time.Now().Add(defaultRateLimitWait)
+		}
+	} else if remainingStr != "" && remaining <= 0 {
+		rateLimitStatus = v2.RateLimitDescription_STATUS_OVERLIMIT
+	}
+
+	rv := &v2.RateLimitDescription{
+		Status:    rateLimitStatus,
+		Limit:     limit,
+		Remaining: remaining,
+	}
+	if !resetAt.IsZero() {
+		rv.ResetAt = timestamppb.New(resetAt)
+	}
+	return rv, true
+}
+
+// withRateLimitData fills the rate limit description returned alongside the results from the Procore headers.
+func withRateLimitData(rateLimit *v2.RateLimitDescription) uhttp.DoOption {
+	return func(resp *uhttp.WrapperResponse) error {
+		desc, ok := parseRateLimit(resp.StatusCode, resp.Header)
+		if !ok {
+			return nil
+		}
+		rateLimit.Status = desc.Status
+		rateLimit.Limit = desc.Limit
+		rateLimit.Remaining = desc.Remaining
+		rateLimit.ResetAt = desc.ResetAt
+		return nil
+	}
+}
+
+// throttle tracks the request budget across every caller of a Client. Requests are spaced out once
+// the remaining budget drops below throttleThreshold, and held until the reset once it runs out.
+type throttle struct {
+	mu        sync.Mutex
+	limit     int64
+	remaining int64
+	resetAt   time.Time
+}
+
+// observe records the budget reported by a response. Concurrent responses can arrive out of order,
+// so those of the current window are only allowed to lower the budget.
+func (t *throttle) observe(desc *v2.RateLimitDescription) {
+	if desc.GetResetAt() == nil {
+		return
+	}
+	resetAt := desc.GetResetAt().AsTime()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	switch {
+	case resetAt.Before(t.resetAt):
+		return
+	case resetAt.Equal(t.resetAt):
+		t.remaining = min(t.remaining, desc.GetRemaining())
+	default:
+		t.remaining = desc.GetRemaining()
+	}
+	t.limit = desc.GetLimit()
+	t.resetAt = resetAt
+}
+
+// hold stops every request until the time Procore asked to wait for after a 429.
+func (t *throttle) hold(until time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.remaining = 0
+	t.resetAt = until
+}
+
+// delay reserves one request from the budget and returns how long to wait before sending it.
+func (t *throttle) delay(now time.Time) time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.resetAt.IsZero() || !now.Before(t.resetAt) {
+		return 0
+	}
+	untilReset := t.resetAt.Sub(now)
+	if t.remaining <= 0 {
+		return untilReset
+	}
+
+	var d time.Duration
+	if t.limit > 0 && t.remaining*100 < t.limit*throttleThreshold {
+		// spread what is left of the budget evenly until the reset
+		d = untilReset / time.Duration(t.remaining)
+	}
+	t.remaining--
+	return d
+}
+
+// wait blocks until the request may be sent or the context is done.
+func (t *throttle) wait(ctx context.Context) error {
+	d := t.delay(time.Now())
+	if d <= 0 {
+		return nil
+	}
+
+	ctxzap.Extract(ctx).Debug("baton-procore: throttling request to stay within the Procore rate limit", zap.Duration("delay", d))
+	return sleep(ctx, d)
+}
+
+// throttledTransport waits for the throttle before every request sent to Procore and records the
+// budget reported by the response. A 429 holds every request of the client until the limit resets.
+type throttledTransport struct {
+	base     http.RoundTripper
+	throttle *throttle
+}
+
+func (t *throttledTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	err := t.throttle.wait(req.Context())
+	if err != nil {
+		// a RoundTripper closes the body even when the request is not sent
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, err
+	}
+
+	res, err := t.base.RoundTrip(req)
+	if res != nil {
+		if desc, ok := parseRateLimit(res.StatusCode, res.Header); ok {
+			if res.StatusCode == http.StatusTooManyRequests {
+				t.throttle.hold(desc.GetResetAt().AsTime())
+			} else {
+				t.throttle.observe(desc)
+			}
+		}
+	}
+	return res, err
+}
@@ -0,0 +1,263 @@
+package client
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestParseRateLimit(t *testing.T) {
+	reset := time.Unix(1700000000, 0)
+
+	tests := []struct {
+		name          string
+		statusCode    int
+		header        map[string]string
+		wantOk        bool
+		wantStatus    v2.RateLimitDescription_Status
+		wantLimit     int64
+		wantRemaining int64
+		// wantResetAt is checked when set, wantResetIn otherwise
+		wantResetAt time.Time
+		wantResetIn time.Duration
+	}{
+		{
+			name:       "no headers",
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "budget left",
+			statusCode: http.StatusOK,
+			header: map[string]string{
+				rateLimitLimitHeader:     "3600",
+				rateLimitRemainingHeader: "3000",
+				rateLimitResetHeader:     "1700000000",
+			},
+			wantOk:        true,
+			wantStatus:    v2.RateLimitDescription_STATUS_OK,
+			wantLimit:     3600,
+			wantRemaining: 3000,
+			wantResetAt:   reset,
+		},
+		{
+			name:       "budget exhausted",
+			statusCode: http.StatusOK,
+			header: map[string]string{
+				rateLimitLimitHeader:     "3600",
+				rateLimitRemainingHeader: "0",
+				rateLimitResetHeader:     "1700000000",
+			},
+			wantOk:      true,
+			wantStatus:  v2.RateLimitDescription_STATUS_OVERLIMIT,
+			wantLimit:   3600,
+			wantResetAt: reset,
+		},
+		{
+			name:       "429 with the reset header",
+			statusCode: http.StatusTooManyRequests,
+			header: map[string]string{
+				rateLimitLimitHeader:     "3600",
+				rateLimitRemainingHeader: "12",
+				rateLimitResetHeader:     "1700000000",
+			},
+			wantOk:      true,
+			wantStatus:  v2.RateLimitDescription_STATUS_OVERLIMIT,
+			wantLimit:   3600,
+			wantResetAt: reset,
+		},
+		{
+			name:        "429 with Retry-After in seconds",
+			statusCode:  http.StatusTooManyRequests,
+			header:      map[string]string{retryAfterHeader: "30"},
+			wantOk:      true,
+			wantStatus:  v2.RateLimitDescription_STATUS_OVERLIMIT,
+			wantResetIn: 30 * time.Second,
+		},
+		{
+			name:        "429 with Retry-After as an HTTP date",
+			statusCode:  http.StatusTooManyRequests,
+			header:      map[string]string{retryAfterHeader: time.Now().Add(2 * time.Minute).UTC().Format(http.TimeFormat)},
+			wantOk:      true,
+			wantStatus:  v2.RateLimitDescription_STATUS_OVERLIMIT,
+			wantResetIn: 2 * time.Minute,
+		},
+		{
+			name:        "429 with a malformed Retry-After",
+			statusCode:  http.StatusTooManyRequests,
+			header:      map[string]string{retryAfterHeader: "soon"},
+			wantOk:      true,
+			wantStatus:  v2.RateLimitDescription_STATUS_OVERLIMIT,
+			wantResetIn: defaultRateLimitWait,
+		},
+		{
+			name:        "429 without a reset time",
+			statusCode:  http.StatusTooManyRequests,
+			wantOk:      true,
+			wantStatus:  v2.RateLimitDescription_STATUS_OVERLIMIT,
+			wantResetIn: defaultRateLimitWait,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{}
+			for k, v := range tt.header {
+				header.Set(k, v)
+			}
+			desc, ok := parseRateLimit(tt.statusCode, header)
+			if ok != tt.wantOk {
+				t.Fatalf("ok = %v, want %v", ok, tt.wantOk)
+			}
+			if !ok {
+				return
+			}
+			if desc.GetStatus() != tt.wantStatus || desc.GetLimit() != tt.wantLimit || desc.GetRemaining() != tt.wantRemaining {
+				t.Fatalf("got status %v limit %d remaining %d, want %v %d %d",
+					desc.GetStatus(), desc.GetLimit(), desc.GetRemaining(), tt.wantStatus, tt.wantLimit, tt.wantRemaining)
+			}
+			resetAt := desc.GetResetAt().AsTime()
+			if !tt.wantResetAt.IsZero() {
+				if !resetAt.Equal(tt.wantResetAt) {
+					t.Fatalf("reset at %v, want %v", resetAt, tt.wantResetAt)
+				}
+				return
+			}
+			if in := time.Until(resetAt); in > tt.wantResetIn || in < tt.wantResetIn-5*time.Second {
+				t.Fatalf("reset in %v, want %v", in, tt.wantResetIn)
+			}
+		})
+	}
+}
+
+func TestThrottleObserve(t *testing.T) {
+	now := time.Now()
+	window := now.Add(time.Hour)
+
+	tests := []struct {
+		name          string
+		resetAt       time.Time
+		remaining     int64
+		wantRemaining int64
+		wantResetAt   time.Time
+	}{
+		{
+			name:          "a newer window replaces the budget",
+			resetAt:       window.Add(time.Hour),
+			remaining:     3000,
+			wantRemaining: 3000,
+			wantResetAt:   window.Add(time.Hour),
+		},
+		{
+			name:          "the same window lowers the budget",
+			resetAt:       window,
+			remaining:     40,
+			wantRemaining: 40,
+			wantResetAt:   window,
+		},
+		{
+			name:          "the same window does not raise the budget",
+			resetAt:       window,
+			remaining:     90,
+			wantRemaining: 50,
+			wantResetAt:   window,
+		},
+		{
+			name:          "an older window is ignored",
+			resetAt:       now,
+			remaining:     3000,
+			wantRemaining: 50,
+			wantResetAt:   window,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			th := &throttle{limit: 100, remaining: 50, resetAt: window}
+			th.observe(&v2.RateLimitDescription{
+				Limit:     100,
+				Remaining: tt.remaining,
+				ResetAt:   timestamppb.New(tt.resetAt),
+			})
+			if th.remaining != tt.wantRemaining || !th.resetAt.Equal(tt.wantResetAt) {
+				t.Fatalf("got remaining %d reset %v, want %d %v", th.remaining, th.resetAt, tt.wantRemaining, tt.wantResetAt)
+			}
+		})
+	}
+
+	t.Run("a response without a reset is ignored", func(t *testing.T) {
+		th := &throttle{limit: 100, remaining: 50, resetAt: window}
+		th.observe(&v2.RateLimitDescription{Limit: 100, Remaining: 0})
+		if th.remaining != 50 {
+			t.Fatalf("remaining = %d, want 50", th.remaining)
+		}
+	})
+}
+
+func TestThrottleDelay(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name          string
+		limit         int64
+		remaining     int64
+		resetAt       time.Time
+		want          time.Duration
+		wantRemaining int64
+	}{
+		{
+			name: "no budget reported yet",
+		},
+		{
+			name:          "the window has reset",
+			limit:         100,
+			remaining:     0,
+			resetAt:       now.Add(-time.Second),
+			want:          0,
+			wantRemaining: 0,
+		},
+		{
+			name:          "above the threshold",
+			limit:         100,
+			remaining:     20,
+			resetAt:       now.Add(100 * time.Second),
+			want:          0,
+			wantRemaining: 19,
+		},
+		{
+			name:          "below the threshold the rest of the budget is spread until the reset",
+			limit:         100,
+			remaining:     10,
+			resetAt:       now.Add(100 * time.Second),
+			want:          10 * time.Second,
+			wantRemaining: 9,
+		},
+		{
+			name:          "the last request of the window",
+			limit:         100,
+			remaining:     1,
+			resetAt:       now.Add(100 * time.Second),
+			want:          100 * time.Second,
+			wantRemaining: 0,
+		},
+		{
+			name:          "an exhausted budget waits for the reset",
+			limit:         100,
+			remaining:     0,
+			resetAt:       now.Add(100 * time.Second),
+			want:          100 * time.Second,
+			wantRemaining: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			th := &throttle{limit: tt.limit, remaining: tt.remaining, resetAt: tt.resetAt}
+			if got := th.delay(now); got != tt.want {
+				t.Fatalf("delay = %v, want %v", got, tt.want)
+			}
+			if th.remaining != tt.wantRemaining {
+				t.Fatalf("remaining = %d, want %d", th.remaining, tt.wantRemaining)
+			}
+		})
+	}
+}
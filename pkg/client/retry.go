@@ -0,0 +1,94 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+const (
+	// DefaultMaxAttempts is how many times a retryable request is sent when no attempt count is configured.
+	DefaultMaxAttempts = 3
+
+	retryBaseDelay = time.Second
+	retryMaxDelay  = 30 * time.Second
+)
+
+// isRetryable reports whether the failure is transient: a 429, a 5xx or a dropped connection.
+func isRetryable(res *http.Response, err error) bool {
+	if errors.Is(err, syscall.ECONNRESET) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF) {
+		return true
+	}
+	if res == nil {
+		return false
+	}
+	return res.StatusCode == http.StatusTooManyRequests || res.StatusCode >= http.StatusInternalServerError
+}
+
+// backoff returns the jittered exponential delay before the next attempt, between half and all
+// of retryBaseDelay doubled for every attempt already made, capped at retryMaxDelay.
+func backoff(attempt int) time.Duration {
+	d := retryMaxDelay
+	if attempt < 16 {
+		d = min(retryBaseDelay<<(attempt-1), retryMaxDelay)
+	}
+	return d/2 + rand.N(d/2+1)
+}
+
+// retryAfter returns the wait Procore asked for in the Retry-After header of the response.
+func retryAfter(res *http.Response) time.Duration {
+	if res == nil {
+		return 0
+	}
+	d, _ := parseRetryAfter(res.Header.Get(retryAfterHeader), time.Now())
+	return d
+}
+
+// parseRetryAfter parses a Retry-After value, given either as seconds or as an HTTP date. It reports
+// false when the value is missing or malformed.
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return max(time.Duration(seconds)*time.Second, 0), true
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		return max(t.Sub(now), 0), true
+	}
+	return 0, false
+}
+
+// rewind returns the request to send for the attempt, with a fresh copy of the body after the first one.
+func rewind(req *http.Request, attempt int) (*http.Request, error) {
+	if attempt == 1 || req.Body == nil || req.Body == http.NoBody {
+		return req, nil
+	}
+	if req.GetBody == nil {
+		return nil, fmt.Errorf("baton-procore: cannot retry %s %s, the request body cannot be replayed", req.Method, req.URL.Path)
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, fmt.Errorf("baton-procore: failed to replay request body: %w", err)
+	}
+	rv := req.Clone(req.Context())
+	rv.Body = body
+	return rv, nil
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
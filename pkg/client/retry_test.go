@@ -0,0 +1,131 @@
+package client
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		value  string
+		want   time.Duration
+		wantOk bool
+	}{
+		{name: "missing"},
+		{name: "seconds", value: "30", want: 30 * time.Second, wantOk: true},
+		{name: "HTTP date", value: now.Add(90 * time.Second).Format(http.TimeFormat), want: 90 * time.Second, wantOk: true},
+		{name: "HTTP date in the past", value: now.Add(-time.Minute).Format(http.TimeFormat), want: 0, wantOk: true},
+		{name: "negative seconds", value: "-5", want: 0, wantOk: true},
+		{name: "malformed", value: "soon"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseRetryAfter(tt.value, now)
+			if got != tt.want || ok != tt.wantOk {
+				t.Fatalf("parseRetryAfter(%q) = %v, %v, want %v, %v", tt.value, got, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+}
+
+func TestBackoffBounds(t *testing.T) {
+	tests := []struct {
+		attempt int
+		ceiling time.Duration
+	}{
+		{attempt: 1, ceiling: retryBaseDelay},
+		{attempt: 2, ceiling: 2 * retryBaseDelay},
+		{attempt: 3, ceiling: 4 * retryBaseDelay},
+		{attempt: 6, ceiling: retryMaxDelay},
+		{attempt: 100, ceiling: retryMaxDelay},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("attempt %d", tt.attempt), func(t *testing.T) {
+			for range 100 {
+				d := backoff(tt.attempt)
+				if d < tt.ceiling/2 || d > tt.ceiling {
+					t.Fatalf("backoff(%d) = %v, want between %v and %v", tt.attempt, d, tt.ceiling/2, tt.ceiling)
+				}
+			}
+		})
+	}
+}
+
+func TestRewind(t *testing.T) {
+	t.Run("the first attempt sends the request as is", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodPost, "https://example.com", strings.NewReader("body"))
+		got, err := rewind(req, 1)
+		if err != nil || got != req {
+			t.Fatalf("rewind = %v, %v, want the original request", got, err)
+		}
+	})
+
+	t.Run("later attempts get a fresh body", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodPost, "https://example.com", bytes.NewReader([]byte("body")))
+		_, _ = io.ReadAll(req.Body)
+
+		got, err := rewind(req, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(got.Body)
+		if string(body) != "body" {
+			t.Fatalf("rewound body = %q, want %q", body, "body")
+		}
+	})
+
+	t.Run("a body without GetBody cannot be replayed", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodPost, "https://example.com", io.NopCloser(strings.NewReader("body")))
+		if _, err := rewind(req, 2); err == nil {
+			t.Fatal("rewind replayed a body it cannot read twice")
+		}
+	})
+
+	t.Run("a request without a body needs no replay", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodGet, "https://example.com", nil)
+		got, err := rewind(req, 2)
+		if err != nil || got != req {
+			t.Fatalf("rewind = %v, %v, want the original request", got, err)
+		}
+	})
+}
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		name       string
+		statusCode int
+		err        error
+		want       bool
+	}{
+		{name: "connection reset", err: fmt.Errorf("read: %w", syscall.ECONNRESET), want: true},
+		{name: "unexpected EOF", err: io.ErrUnexpectedEOF, want: true},
+		{name: "EOF", err: io.EOF, want: true},
+		{name: "other transport error", err: errors.New("no such host")},
+		{name: "429", statusCode: http.StatusTooManyRequests, err: errors.New("status"), want: true},
+		{name: "500", statusCode: http.StatusInternalServerError, err: errors.New("status"), want: true},
+		{name: "503", statusCode: http.StatusServiceUnavailable, err: errors.New("status"), want: true},
+		{name: "404", statusCode: http.StatusNotFound, err: errors.New("status")},
+		{name: "422", statusCode: http.StatusUnprocessableEntity, err: errors.New("status")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var res *http.Response
+			if tt.statusCode != 0 {
+				res = &http.Response{StatusCode: tt.statusCode}
+			}
+			if got := isRetryable(res, tt.err); got != tt.want {
+				t.Fatalf("isRetryable = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,18 +1,106 @@
 package client
 
+// Procore runs production and the developer sandbox on separate hosts, each with its own login.
+// https://developers.procore.com/documentation/development-environments
 const (
-	BaseURL         = "https://api.procore.com/rest"
-	GetCompaniesURL = BaseURL + "/v1.0/companies"
-	GetProjectsURL  = BaseURL + "/v1.1/projects"
+	ProductionBaseURL  = "https://api.procore.com/rest"
+	ProductionTokenURL = "https://login.procore.com/oauth/token"
+
+	SandboxBaseURL  = "https://sandbox.procore.com/rest"
+	SandboxTokenURL = "https://login-sandbox.procore.com/oauth/token"
+)
+
+// Environment is the pair of URLs a Client talks to.
+type Environment struct {
+	BaseURL  string
+	TokenURL string
+}
+
+// environments are the presets accepted by the environment configuration field.
+var environments = map[string]Environment{
+	"production": {BaseURL: ProductionBaseURL, TokenURL: ProductionTokenURL},
+	"sandbox":    {BaseURL: SandboxBaseURL, TokenURL: SandboxTokenURL},
+}
+
+// LookupEnvironment returns the URLs of the named environment preset, "production" or "sandbox".
+func LookupEnvironment(name string) (Environment, bool) {
+	environment, ok := environments[name]
+	return environment, ok
+}
+
+// The paths below are relative to the configured base URL and joined to it by Client.url.
+const (
+	GetCompaniesPath = "/v1.0/companies"
+	GetProjectsPath  = "/v1.1/projects"
 
 	// https://developers.procore.com/reference/rest/company-users?version=latest
-	CompanyUsersURL = BaseURL + "/v1.3/companies/%s/users"
+	CompanyUsersPath = "/v1.3/companies/%s/users"
 
-	ProjectUsersURL = BaseURL + "/v1.0/projects/%s/users"
+	// https://developers.procore.com/reference/rest/company-users?version=latest#show-company-user
+	CompanyUserPath = CompanyUsersPath + "/%d"
+
+	// https://developers.procore.com/reference/rest/company-users?version=latest
+	CompanyUserProjectsPath = "/v1.0/companies/%s/users/%d/projects"
+
+	// https://developers.procore.com/reference/rest/company-people?version=latest
+	CompanyPeoplePath = "/v1.0/companies/%s/people"
+
+	ProjectUsersPath = "/v1.0/projects/%s/users"
+
+	// https://developers.procore.com/reference/rest/project-users?version=latest#show-project-user
+	ProjectUserPath = ProjectUsersPath + "/%d"
 
 	// https://developers.procore.com/reference/rest/project-users?version=latest#add-company-user-to-project
-	AddUserToProjectURL = ProjectUsersURL + "/%d/actions/add"
+	AddUserToProjectPath = ProjectUsersPath + "/%d/actions/add"
 
 	// https://developers.procore.com/reference/rest/project-users?version=latest#remove-a-user-from-the-project
-	RemoveUserFromProjectURL = ProjectUsersURL + "/%d/actions/remove"
+	RemoveUserFromProjectPath = ProjectUsersPath + "/%d/actions/remove"
+
+	// https://developers.procore.com/reference/rest/company-permission-templates?version=latest
+	CompanyPermissionTemplatesPath = "/v1.0/companies/%s/permission_templates"
+
+	// https://developers.procore.com/reference/rest/company-vendors?version=latest
+	VendorsPath = "/v1.0/vendors"
+
+	// https://developers.procore.com/reference/rest/project-vendors?version=latest
+	ProjectVendorsPath = "/v1.0/projects/%s/vendors"
+
+	// https://developers.procore.com/reference/rest/project-vendors?version=latest#add-company-vendor-to-project
+	AddVendorToProjectPath = ProjectVendorsPath + "/%d/actions/add"
+
+	// https://developers.procore.com/reference/rest/project-vendors?version=latest#remove-a-vendor-from-the-project
+	RemoveVendorFromProjectPath = ProjectVendorsPath + "/%d/actions/remove"
+
+	// https://developers.procore.com/reference/rest/project-permission-templates?version=latest
+	ProjectPermissionTemplatesPath = "/v1.0/projects/%s/permission_templates"
+
+	// https://developers.procore.com/reference/rest/distribution-groups?version=latest
+	DistributionGroupsPath = "/v1.0/projects/%s/distribution_groups"
+
+	DistributionGroupUsersPath = DistributionGroupsPath + "/%d/users"
+
+	// https://developers.procore.com/reference/rest/distribution-groups?version=latest#add-user-to-distribution-group
+	AddUserToDistributionGroupPath = DistributionGroupUsersPath + "/%d/actions/add"
+
+	// https://developers.procore.com/reference/rest/distribution-groups?version=latest#remove-user-from-distribution-group
+	RemoveUserFromDistributionGroupPath = DistributionGroupUsersPath + "/%d/actions/remove"
+
+	// https://developers.procore.com/reference/rest/project-roles?version=latest
+	ProjectRolesPath = "/v1.0/project_roles"
+
+	// https://developers.procore.com/reference/rest/project-roles?version=latest#update-project-role
+	ProjectRolePath = ProjectRolesPath + "/%d"
+)
+
+// The absolute production URLs, kept for callers of the package.
+//
+// Deprecated: Client builds its URLs from the configured base URL and the paths above.
+const (
+	BaseURL                  = ProductionBaseURL
+	GetCompaniesURL          = BaseURL + GetCompaniesPath
+	GetProjectsURL           = BaseURL + GetProjectsPath
+	CompanyUsersURL          = BaseURL + CompanyUsersPath
+	ProjectUsersURL          = BaseURL + ProjectUsersPath
+	AddUserToProjectURL      = BaseURL + AddUserToProjectPath
+	RemoveUserFromProjectURL = BaseURL + RemoveUserFromProjectPath
 )
@@ -4,15 +4,17 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"strings"
 
 	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
 	"github.com/conductorone/baton-sdk/pkg/uhttp"
 )
 
 func (c *Client) GetCompanyUsers(ctx context.Context, companyId string, page int) ([]User, *http.Response, *v2.RateLimitDescription, error) {
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf(CompanyUsersURL, companyId), nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url(CompanyUsersPath, companyId), nil)
 	if err != nil {
 		return nil, nil, nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -23,26 +25,20 @@ func (c *Client) GetCompanyUsers(ctx context.Context, companyId string, page int
 
 	var target []User
 	var rateLimitData v2.RateLimitDescription
-	res, err := c.Do(req,
+	res, err := c.do(req,
 		uhttp.WithJSONResponse(&target),
-		uhttp.WithRatelimitData(&rateLimitData),
+		withRateLimitData(&rateLimitData),
 	)
 	if err != nil {
-		logBody(ctx, res.Body)
 		return nil, nil, nil, fmt.Errorf("error getting company users from Procore API: %w", err)
 	}
 
 	defer res.Body.Close()
-	if res.StatusCode < http.StatusOK || res.StatusCode >= http.StatusMultipleChoices {
-		logBody(ctx, res.Body)
-		return nil, nil, nil, fmt.Errorf("unexpected status code: %d, expected: %d", res.StatusCode, http.StatusOK)
-	}
-
 	return target, res, &rateLimitData, nil
 }
 
 func (c *Client) GetProjectUsers(ctx context.Context, companyId, projectId string, page int) ([]User, *http.Response, *v2.RateLimitDescription, error) {
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf(ProjectUsersURL, projectId), nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url(ProjectUsersPath, projectId), nil)
 	if err != nil {
 		return nil, nil, nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -56,47 +52,188 @@ func (c *Client) GetProjectUsers(ctx context.Context, companyId, projectId strin
 
 	var target []User
 	var rateLimitData v2.RateLimitDescription
-	res, err := c.Do(req,
+	res, err := c.do(req,
 		uhttp.WithJSONResponse(&target),
-		uhttp.WithRatelimitData(&rateLimitData),
+		withRateLimitData(&rateLimitData),
 	)
 	if err != nil {
 		return nil, nil, nil, fmt.Errorf("error getting project users from Procore API: %w", err)
 	}
 
 	defer res.Body.Close()
-	if res.StatusCode < http.StatusOK || res.StatusCode >= http.StatusMultipleChoices {
-		logBody(ctx, res.Body)
-		return nil, nil, nil, fmt.Errorf("unexpected status code: %d, expected: %d", res.StatusCode, http.StatusOK)
-	}
-
 	return target, res, &rateLimitData, nil
 }
 
-func (c *Client) CreateCompanyUser(ctx context.Context, companyId string, body CreateUserBody) error {
+// FindProjectUserByEmail returns nil when no user of the project directory has the email address.
+func (c *Client) FindProjectUserByEmail(ctx context.Context, companyId, projectId, email string) (*User, error) {
+	page := 1
+	for {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url(ProjectUsersPath, projectId), nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
+		req.Header.Set("Procore-Company-Id", companyId)
+		values := req.URL.Query()
+		values.Set("filters[search]", email)
+		values.Set("page", fmt.Sprintf("%d", page))
+		values.Set("per_page", fmt.Sprintf("%d", perPage))
+		req.URL.RawQuery = values.Encode()
+
+		var target []User
+		res, err := c.do(req, uhttp.WithJSONResponse(&target))
+		if err != nil {
+			return nil, fmt.Errorf("error searching project users in Procore API: %w", err)
+		}
+		res.Body.Close()
+
+		for _, user := range target {
+			if strings.EqualFold(user.EmailAddress, email) {
+				return &user, nil
+			}
+		}
+		if !HasNextPage(res) {
+			return nil, nil
+		}
+		page++
+	}
+}
+
+// ErrUserAlreadyExists is returned by CreateCompanyUser when the email address is already taken.
+var ErrUserAlreadyExists = errors.New("baton-procore: user already exists")
+
+// CreateCompanyUser returns the created user as reported by Procore.
+func (c *Client) CreateCompanyUser(ctx context.Context, companyId string, body CreateUserBody) (*User, error) {
 	jsonBody, err := json.Marshal(body)
 	if err != nil {
-		return fmt.Errorf("failed to marshal user: %w", err)
+		return nil, fmt.Errorf("failed to marshal user: %w", err)
 	}
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf(CompanyUsersURL, companyId), bytes.NewReader(jsonBody))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url(CompanyUsersPath, companyId), bytes.NewReader(jsonBody))
 	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	req.Header.Set("Procore-Company-Id", companyId)
 	req.Header.Set("Content-Type", "application/json")
 
-	res, err := c.Do(req)
+	var target User
+	res, err := c.do(req, uhttp.WithJSONResponse(&target))
+	if err != nil {
+		if isEmailTaken(err) {
+			return nil, ErrUserAlreadyExists
+		}
+		return nil, fmt.Errorf("error creating company user in Procore API: %w", err)
+	}
+
+	defer res.Body.Close()
+	return &target, nil
+}
+
+// isEmailTaken reports whether Procore rejected a user because of a duplicated email address.
+func isEmailTaken(err error) bool {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	if apiErr.StatusCode != http.StatusUnprocessableEntity && apiErr.StatusCode != http.StatusConflict {
+		return false
+	}
+	return strings.Contains(apiErr.Message, "already been taken")
+}
+
+// FindCompanyUserByEmail returns nil when no company user has the email address.
+func (c *Client) FindCompanyUserByEmail(ctx context.Context, companyId, email string) (*User, error) {
+	page := 1
+	for {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url(CompanyUsersPath, companyId), nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
+		values := req.URL.Query()
+		values.Set("filters[search]", email)
+		values.Set("page", fmt.Sprintf("%d", page))
+		values.Set("per_page", fmt.Sprintf("%d", perPage))
+		req.URL.RawQuery = values.Encode()
+
+		var target []User
+		res, err := c.do(req, uhttp.WithJSONResponse(&target))
+		if err != nil {
+			return nil, fmt.Errorf("error searching company users in Procore API: %w", err)
+		}
+		res.Body.Close()
+
+		for _, user := range target {
+			if strings.EqualFold(user.EmailAddress, email) {
+				return &user, nil
+			}
+		}
+		if !HasNextPage(res) {
+			return nil, nil
+		}
+		page++
+	}
+}
+
+// https://developers.procore.com/reference/rest/company-users?version=latest#show-company-user
+func (c *Client) GetCompanyUser(ctx context.Context, companyId string, userId int) (*User, *v2.RateLimitDescription, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url(CompanyUserPath, companyId, userId), nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Procore-Company-Id", companyId)
+
+	var target User
+	var rateLimitData v2.RateLimitDescription
+	res, err := c.do(req,
+		uhttp.WithJSONResponse(&target),
+		withRateLimitData(&rateLimitData),
+	)
 	if err != nil {
-		logBody(ctx, res.Body)
-		return fmt.Errorf("error creating company user in Procore API: %w", err)
+		return nil, nil, fmt.Errorf("error getting company user from Procore API: %w", err)
 	}
 
 	defer res.Body.Close()
-	if res.StatusCode < http.StatusOK || res.StatusCode >= http.StatusMultipleChoices {
-		logBody(ctx, res.Body)
-		return fmt.Errorf("unexpected status code: %d, expected: %d", res.StatusCode, http.StatusOK)
+	return &target, &rateLimitData, nil
+}
+
+// UpdateCompanyUser returns the updated user as reported by Procore.
+// https://developers.procore.com/reference/rest/company-users?version=latest#update-company-user
+func (c *Client) UpdateCompanyUser(ctx context.Context, companyId string, userId int, body UpdateUserBody) (*User, error) {
+	jsonBody, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal user: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPatch, c.url(CompanyUserPath, companyId, userId), bytes.NewReader(jsonBody))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	return nil
+	req.Header.Set("Procore-Company-Id", companyId)
+	req.Header.Set("Content-Type", "application/json")
+
+	var target User
+	res, err := c.do(req, uhttp.WithJSONResponse(&target))
+	if err != nil {
+		return nil, fmt.Errorf("error updating company user in Procore API: %w", err)
+	}
+
+	defer res.Body.Close()
+	return &target, nil
+}
+
+func (c *Client) SetCompanyPermissionTemplate(ctx context.Context, companyId string, userId, templateId int) error {
+	_, err := c.UpdateCompanyUser(ctx, companyId, userId, UpdateUserBody{
+		User: UserUpdate{
+			CompanyPermissionTemplateId: &templateId,
+		},
+	})
+	return err
+}
+
+func (c *Client) SetCompanyUserActive(ctx context.Context, companyId string, userId int, active bool) (*User, error) {
+	return c.UpdateCompanyUser(ctx, companyId, userId, UpdateUserBody{
+		User: UserUpdate{
+			IsActive: &active,
+		},
+	})
 }
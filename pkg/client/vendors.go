@@ -0,0 +1,101 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/uhttp"
+)
+
+// https://developers.procore.com/reference/rest/company-vendors?version=latest#list-company-vendors
+func (c *Client) GetCompanyVendors(ctx context.Context, companyId string, page int) ([]Vendor, *http.Response, *v2.RateLimitDescription, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url(VendorsPath), nil)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Procore-Company-Id", companyId)
+	values := req.URL.Query()
+	values.Set("company_id", companyId)
+	values.Set("page", fmt.Sprintf("%d", page))
+	values.Set("per_page", fmt.Sprintf("%d", perPage))
+	req.URL.RawQuery = values.Encode()
+
+	var target []Vendor
+	var rateLimitData v2.RateLimitDescription
+	res, err := c.do(req,
+		uhttp.WithJSONResponse(&target),
+		withRateLimitData(&rateLimitData),
+	)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("baton-procore: error getting vendors: %w", err)
+	}
+
+	defer res.Body.Close()
+	return target, res, &rateLimitData, nil
+}
+
+// https://developers.procore.com/reference/rest/project-vendors?version=latest#list-project-vendors
+func (c *Client) GetProjectVendors(ctx context.Context, companyId, projectId string, page int) ([]Vendor, *http.Response, *v2.RateLimitDescription, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url(ProjectVendorsPath, projectId), nil)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Procore-Company-Id", companyId)
+	values := req.URL.Query()
+	values.Set("page", fmt.Sprintf("%d", page))
+	values.Set("per_page", fmt.Sprintf("%d", perPage))
+	req.URL.RawQuery = values.Encode()
+
+	var target []Vendor
+	var rateLimitData v2.RateLimitDescription
+	res, err := c.do(req,
+		uhttp.WithJSONResponse(&target),
+		withRateLimitData(&rateLimitData),
+	)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("baton-procore: error getting project vendors: %w", err)
+	}
+
+	defer res.Body.Close()
+	return target, res, &rateLimitData, nil
+}
+
+// https://developers.procore.com/reference/rest/project-vendors?version=latest#add-company-vendor-to-project
+func (c *Client) AddVendorToProject(ctx context.Context, companyId, projectId string, vendorId int) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url(AddVendorToProjectPath, projectId, vendorId), nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Procore-Company-Id", companyId)
+
+	res, err := c.doIdempotent(req)
+	if err != nil {
+		return fmt.Errorf("baton-procore: error adding vendor to project: %w", err)
+	}
+
+	defer res.Body.Close()
+	return nil
+}
+
+// https://developers.procore.com/reference/rest/project-vendors?version=latest#remove-a-vendor-from-the-project
+func (c *Client) RemoveVendorFromProject(ctx context.Context, companyId, projectId string, vendorId int) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, c.url(RemoveVendorFromProjectPath, projectId, vendorId), nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Procore-Company-Id", companyId)
+
+	res, err := c.doIdempotent(req)
+	if err != nil {
+		return fmt.Errorf("baton-procore: error removing vendor from project: %w", err)
+	}
+
+	defer res.Body.Close()
+	return nil
+}
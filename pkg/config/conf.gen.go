@@ -6,6 +6,22 @@ import "reflect"
 type Procore struct {
 	ProcoreClientId string `mapstructure:"procore-client-id"`
 	ProcoreClientSecret string `mapstructure:"procore-client-secret"`
+	DefaultCompanyPermissionTemplate string `mapstructure:"default-company-permission-template"`
+	RemoveFromProjectsOnDelete bool `mapstructure:"remove-from-projects-on-delete"`
+	IncludeCompanyIds []string `mapstructure:"include-company-ids"`
+	ExcludeCompanyIds []string `mapstructure:"exclude-company-ids"`
+	ActiveProjectsOnly bool `mapstructure:"active-projects-only"`
+	SkipDemoProjects bool `mapstructure:"skip-demo-projects"`
+	IncludeProjectStages []string `mapstructure:"include-project-stages"`
+	ExcludeProjectStages []string `mapstructure:"exclude-project-stages"`
+	IncludeProjectTypes []string `mapstructure:"include-project-types"`
+	ExcludeProjectTypes []string `mapstructure:"exclude-project-types"`
+	IncludeProjectRegionIds []string `mapstructure:"include-project-region-ids"`
+	ExcludeProjectRegionIds []string `mapstructure:"exclude-project-region-ids"`
+	RetryMaxAttempts int `mapstructure:"retry-max-attempts"`
+	Environment string `mapstructure:"environment"`
+	BaseUrl string `mapstructure:"base-url"`
+	TokenUrl string `mapstructure:"token-url"`
 }
 
 func (c* Procore) findFieldByTag(tagValue string) (any, bool) {
@@ -1,6 +1,7 @@
 package config
 
 import (
+	"github.com/conductorone/baton-procore/pkg/client"
 	"github.com/conductorone/baton-sdk/pkg/field"
 )
 
@@ -21,7 +22,133 @@ var (
 		field.WithIsSecret(true),
 	)
 
-	ConfigurationFields = []field.SchemaField{ClientId, ClientSecret}
+	DefaultCompanyPermissionTemplate = field.StringField(
+		"default-company-permission-template",
+		field.WithDescription("The name or ID of the company permission template assigned to a user when their company permission template is revoked."),
+		field.WithDisplayName("Default Company Permission Template"),
+	)
+
+	RemoveFromProjectsOnDelete = field.BoolField(
+		"remove-from-projects-on-delete",
+		field.WithDescription("When deleting a user, also remove them from every project they belong to before deactivating them."),
+		field.WithDisplayName("Remove From Projects On Delete"),
+	)
+
+	IncludeCompanyIds = field.StringSliceField(
+		"include-company-ids",
+		field.WithDescription("The IDs of the companies to sync and provision. When empty, every company the app is installed in is used."),
+		field.WithDisplayName("Include Company IDs"),
+	)
+
+	ExcludeCompanyIds = field.StringSliceField(
+		"exclude-company-ids",
+		field.WithDescription("The IDs of the companies to skip, such as demo or training companies. Takes precedence over the included companies."),
+		field.WithDisplayName("Exclude Company IDs"),
+	)
+
+	ActiveProjectsOnly = field.BoolField(
+		"active-projects-only",
+		field.WithDescription("Only sync active projects."),
+		field.WithDisplayName("Active Projects Only"),
+	)
+
+	SkipDemoProjects = field.BoolField(
+		"skip-demo-projects",
+		field.WithDescription("Do not sync demo projects."),
+		field.WithDisplayName("Skip Demo Projects"),
+	)
+
+	IncludeProjectStages = field.StringSliceField(
+		"include-project-stages",
+		field.WithDescription("The names or IDs of the project stages to sync. When empty, projects of every stage are synced."),
+		field.WithDisplayName("Include Project Stages"),
+	)
+
+	ExcludeProjectStages = field.StringSliceField(
+		"exclude-project-stages",
+		field.WithDescription("The names or IDs of the project stages to skip."),
+		field.WithDisplayName("Exclude Project Stages"),
+	)
+
+	IncludeProjectTypes = field.StringSliceField(
+		"include-project-types",
+		field.WithDescription("The names or IDs of the project types to sync. When empty, projects of every type are synced."),
+		field.WithDisplayName("Include Project Types"),
+	)
+
+	ExcludeProjectTypes = field.StringSliceField(
+		"exclude-project-types",
+		field.WithDescription("The names or IDs of the project types to skip."),
+		field.WithDisplayName("Exclude Project Types"),
+	)
+
+	IncludeProjectRegionIds = field.StringSliceField(
+		"include-project-region-ids",
+		field.WithDescription("The IDs of the project regions to sync. When empty, projects of every region are synced."),
+		field.WithDisplayName("Include Project Region IDs"),
+	)
+
+	ExcludeProjectRegionIds = field.StringSliceField(
+		"exclude-project-region-ids",
+		field.WithDescription("The IDs of the project regions to skip."),
+		field.WithDisplayName("Exclude Project Region IDs"),
+	)
+
+	RetryMaxAttempts = field.IntField(
+		"retry-max-attempts",
+		field.WithDescription("How many times a Procore request is attempted when it fails with a 429, a 5xx or a connection reset. Applies to reads and to adding or removing project users and vendors."),
+		field.WithDisplayName("Retry Max Attempts"),
+		field.WithDefaultValue(client.DefaultMaxAttempts),
+	)
+
+	Environment = field.StringField(
+		"environment",
+		field.WithDescription("The Procore environment to connect to: production or sandbox. Sets the API and login URLs unless they are given explicitly."),
+		field.WithDisplayName("Environment"),
+		field.WithDefaultValue("production"),
+		field.WithString(func(r *field.StringRuler) {
+			r.In([]string{"production", "sandbox"})
+		}),
+	)
+
+	BaseURL = field.StringField(
+		"base-url",
+		field.WithDescription("The Procore REST API base URL, e.g. https://sandbox.procore.com/rest. Overrides the environment."),
+		field.WithDisplayName("Base URL"),
+		field.WithString(func(r *field.StringRuler) {
+			r.IsURI()
+		}),
+	)
+
+	TokenURL = field.StringField(
+		"token-url",
+		field.WithDescription("The Procore OAuth token URL, e.g. https://login-sandbox.procore.com/oauth/token. Overrides the environment."),
+		field.WithDisplayName("Token URL"),
+		field.WithString(func(r *field.StringRuler) {
+			r.IsURI()
+		}),
+	)
+
+	ConfigurationFields = []field.SchemaField{
+		ClientId,
+		ClientSecret,
+		DefaultCompanyPermissionTemplate,
+		RemoveFromProjectsOnDelete,
+		IncludeCompanyIds,
+		ExcludeCompanyIds,
+		ActiveProjectsOnly,
+		SkipDemoProjects,
+		IncludeProjectStages,
+		ExcludeProjectStages,
+		IncludeProjectTypes,
+		ExcludeProjectTypes,
+		IncludeProjectRegionIds,
+		ExcludeProjectRegionIds,
+		RetryMaxAttempts,
+		Environment,
+		BaseURL,
+		TokenURL,
+	}
 
 	// FieldRelationships defines relationships between the ConfigurationFields that can be automatically validated.
 	// For example, a username and password can be required together, or an access token can be
@@ -0,0 +1,313 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+
+	"github.com/conductorone/baton-procore/pkg/client"
+	config "github.com/conductorone/baton-sdk/pb/c1/config/v1"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/actions"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/conductorone/baton-sdk/pkg/connectorbuilder"
+	resourceSdk "github.com/conductorone/baton-sdk/pkg/types/resource"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+const (
+	disableUserAction = "disable_user"
+	enableUserAction  = "enable_user"
+	updateUserAction  = "update_user"
+)
+
+// userActionArguments are shared by the account actions, which target a single company user.
+var userActionArguments = []*config.Field{
+	{
+		Name:        "company_id",
+		DisplayName: "Company ID",
+		Description: "The ID of the company the user belongs to.",
+		Field:       &config.Field_StringField{},
+		IsRequired:  true,
+	},
+	{
+		Name:        "user_id",
+		DisplayName: "User ID",
+		Description: "The ID of the company user.",
+		Field:       &config.Field_StringField{},
+		IsRequired:  true,
+	},
+}
+
+var userActionReturnTypes = []*config.Field{
+	{
+		Name:        "success",
+		DisplayName: "Success",
+		Field:       &config.Field_BoolField{},
+	},
+	{
+		Name:        "resource",
+		DisplayName: "User",
+		Description: "The refreshed user resource: its resource_type, resource_id, display_name and status.",
+		Field:       &config.Field_StringMapField{},
+	},
+}
+
+var disableUserActionSchema = &v2.BatonActionSchema{
+	Name:        disableUserAction,
+	DisplayName: "Disable User",
+	Description: "Suspend a company user by marking them inactive. Their project history is kept.",
+	Arguments:   userActionArguments,
+	ReturnTypes: userActionReturnTypes,
+}
+
+var enableUserActionSchema = &v2.BatonActionSchema{
+	Name:        enableUserAction,
+	DisplayName: "Enable User",
+	Description: "Reactivate a suspended company user.",
+	Arguments:   userActionArguments,
+	ReturnTypes: userActionReturnTypes,
+}
+
+// userProfileField maps an optional update_user argument to the company user attribute it changes.
+type userProfileField struct {
+	name        string
+	displayName string
+	current     func(user client.User) string
+	set         func(update *client.UserUpdate, value *string)
+}
+
+var userProfileFields = []userProfileField{
+	{
+		name:        "first_name",
+		displayName: "First Name",
+		current:     func(user client.User) string { return user.FirstName },
+		set:         func(update *client.UserUpdate, value *string) { update.FirstName = value },
+	},
+	{
+		name:        "last_name",
+		displayName: "Last Name",
+		current:     func(user client.User) string { return user.LastName },
+		set:         func(update *client.UserUpdate, value *string) { update.LastName = value },
+	},
+	{
+		name:        "job_title",
+		displayName: "Job Title",
+		current:     func(user client.User) string { return user.JobTitle },
+		set:         func(update *client.UserUpdate, value *string) { update.JobTitle = value },
+	},
+	{
+		name:        "business_phone",
+		displayName: "Business Phone",
+		current:     func(user client.User) string { return user.BusinessPhone },
+		set:         func(update *client.UserUpdate, value *string) { update.BusinessPhone = value },
+	},
+	{
+		name:        "mobile_phone",
+		displayName: "Mobile Phone",
+		current:     func(user client.User) string { return user.MobilePhone },
+		set:         func(update *client.UserUpdate, value *string) { update.MobilePhone = value },
+	},
+	{
+		name:        "employee_id",
+		displayName: "Employee ID",
+		current:     func(user client.User) string { return user.EmployeeId },
+		set:         func(update *client.UserUpdate, value *string) { update.EmployeeId = value },
+	},
+	{
+		name:        "address",
+		displayName: "Address",
+		current:     func(user client.User) string { return user.Address },
+		set:         func(update *client.UserUpdate, value *string) { update.Address = value },
+	},
+	{
+		name:        "city",
+		displayName: "City",
+		current:     func(user client.User) string { return user.City },
+		set:         func(update *client.UserUpdate, value *string) { update.City = value },
+	},
+	{
+		name:        "state_code",
+		displayName: "State Code",
+		current:     func(user client.User) string { return user.StateCode },
+		set:         func(update *client.UserUpdate, value *string) { update.StateCode = value },
+	},
+	{
+		name:        "zip",
+		displayName: "ZIP",
+		current:     func(user client.User) string { return user.Zip },
+		set:         func(update *client.UserUpdate, value *string) { update.Zip = value },
+	},
+	{
+		name:        "country_code",
+		displayName: "Country Code",
+		current:     func(user client.User) string { return user.CountryCode },
+		set:         func(update *client.UserUpdate, value *string) { update.CountryCode = value },
+	},
+}
+
+func updateUserActionArguments() []*config.Field {
+	fields := slices.Clone(userActionArguments)
+	for _, f := range userProfileFields {
+		fields = append(fields, &config.Field{
+			Name:        f.name,
+			DisplayName: f.displayName,
+			Description: "Leave empty to keep the current value, or set to null to clear it.",
+			Field:       &config.Field_StringField{},
+		})
+	}
+	return fields
+}
+
+var updateUserActionSchema = &v2.BatonActionSchema{
+	Name:        updateUserAction,
+	DisplayName: "Update User",
+	Description: "Update the profile attributes of a company user. Only attributes that differ from the current values are sent.",
+	Arguments:   updateUserActionArguments(),
+	ReturnTypes: userActionReturnTypes,
+}
+
+// RegisterActionManager registers the custom account actions of the connector.
+func (d *Connector) RegisterActionManager(ctx context.Context) (connectorbuilder.CustomActionManager, error) {
+	actionManager := actions.NewActionManager(ctx)
+
+	err := actionManager.RegisterAction(ctx, disableUserAction, disableUserActionSchema, d.setUserActiveHandler(false))
+	if err != nil {
+		return nil, fmt.Errorf("baton-procore: error registering %s action: %w", disableUserAction, err)
+	}
+	err = actionManager.RegisterAction(ctx, enableUserAction, enableUserActionSchema, d.setUserActiveHandler(true))
+	if err != nil {
+		return nil, fmt.Errorf("baton-procore: error registering %s action: %w", enableUserAction, err)
+	}
+	err = actionManager.RegisterAction(ctx, updateUserAction, updateUserActionSchema, d.updateUserHandler)
+	if err != nil {
+		return nil, fmt.Errorf("baton-procore: error registering %s action: %w", updateUserAction, err)
+	}
+
+	return actionManager, nil
+}
+
+func (d *Connector) setUserActiveHandler(active bool) actions.ActionHandler {
+	return func(ctx context.Context, args *structpb.Struct) (*structpb.Struct, annotations.Annotations, error) {
+		companyId, userId, err := d.getUserActionTarget(args)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		user, err := d.client.SetCompanyUserActive(ctx, companyId, userId, active)
+		if err != nil {
+			return nil, nil, fmt.Errorf("baton-procore: error updating company user: %w", err)
+		}
+
+		return d.userActionResult(ctx, companyId, user)
+	}
+}
+
+func (d *Connector) updateUserHandler(ctx context.Context, args *structpb.Struct) (*structpb.Struct, annotations.Annotations, error) {
+	companyId, userId, err := d.getUserActionTarget(args)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// the diff must see changes made since the sync, or reverting one of them would be dropped
+	user, _, err := d.client.Uncached().GetCompanyUser(ctx, companyId, userId)
+	if err != nil {
+		return nil, nil, fmt.Errorf("baton-procore: error getting company user: %w", err)
+	}
+
+	update, changed := diffUserProfile(*user, args)
+	if changed {
+		user, err = d.client.UpdateCompanyUser(ctx, companyId, userId, client.UpdateUserBody{User: update})
+		if err != nil {
+			return nil, nil, fmt.Errorf("baton-procore: error updating company user: %w", err)
+		}
+	}
+
+	return d.userActionResult(ctx, companyId, user)
+}
+
+// diffUserProfile builds an update holding only the profile arguments that differ from the user's current values.
+func diffUserProfile(user client.User, args *structpb.Struct) (client.UserUpdate, bool) {
+	var update client.UserUpdate
+	changed := false
+	for _, f := range userProfileFields {
+		value, ok := getProfileArg(args, f.name)
+		if !ok || value == f.current(user) {
+			continue
+		}
+		f.set(&update, &value)
+		changed = true
+	}
+	return update, changed
+}
+
+func (d *Connector) getUserActionTarget(args *structpb.Struct) (string, int, error) {
+	companyId, ok := getStringArg(args, "company_id")
+	if !ok {
+		return "", 0, fmt.Errorf("baton-procore: missing company_id argument")
+	}
+	if !d.companyFilter.allows(companyId) {
+		return "", 0, fmt.Errorf("baton-procore: company %s is excluded by the configuration", companyId)
+	}
+	rawUserId, ok := getStringArg(args, "user_id")
+	if !ok {
+		return "", 0, fmt.Errorf("baton-procore: missing user_id argument")
+	}
+	userId, err := strconv.Atoi(rawUserId)
+	if err != nil {
+		return "", 0, fmt.Errorf("baton-procore: failed to parse user_id argument: %w", err)
+	}
+	return companyId, userId, nil
+}
+
+// userActionResult returns the refreshed user resource, built from every company the user belongs to.
+func (d *Connector) userActionResult(ctx context.Context, companyId string, user *client.User) (*structpb.Struct, annotations.Annotations, error) {
+	memberships, err := d.companyUsers.readMemberships(ctx, user.Id, &companyUserRecord{companyId: companyId, user: user})
+	if err != nil {
+		return nil, nil, err
+	}
+	resource, err := userResource(memberships)
+	if err != nil {
+		return nil, nil, fmt.Errorf("baton-procore: error converting user to resource: %w", err)
+	}
+	trait, err := resourceSdk.GetUserTrait(resource)
+	if err != nil {
+		return nil, nil, fmt.Errorf("baton-procore: error getting user trait: %w", err)
+	}
+
+	return &structpb.Struct{
+		Fields: map[string]*structpb.Value{
+			"success": structpb.NewBoolValue(true),
+			"resource": structpb.NewStructValue(&structpb.Struct{
+				Fields: map[string]*structpb.Value{
+					"resource_type": structpb.NewStringValue(resource.Id.ResourceType),
+					"resource_id":   structpb.NewStringValue(resource.Id.Resource),
+					"display_name":  structpb.NewStringValue(resource.DisplayName),
+					"status":        structpb.NewStringValue(trait.GetStatus().GetStatus().String()),
+				},
+			}),
+		},
+	}, nil, nil
+}
+
+// getProfileArg reads an optional update_user argument. A null argument clears the attribute, so it
+// is returned as an empty value, while a missing or empty one keeps the current value.
+func getProfileArg(args *structpb.Struct, name string) (string, bool) {
+	if _, ok := args.GetFields()[name].GetKind().(*structpb.Value_NullValue); ok {
+		return "", true
+	}
+	return getStringArg(args, name)
+}
+
+func getStringArg(args *structpb.Struct, name string) (string, bool) {
+	value, ok := args.GetFields()[name]
+	if !ok {
+		return "", false
+	}
+	s, ok := value.GetKind().(*structpb.Value_StringValue)
+	if !ok || s.StringValue == "" {
+		return "", false
+	}
+	return s.StringValue, true
+}
@@ -0,0 +1,61 @@
+package connector
+
+import (
+	"testing"
+
+	"github.com/conductorone/baton-procore/pkg/client"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestDiffUserProfile(t *testing.T) {
+	user := client.User{FirstName: "Ada", JobTitle: "Engineer"}
+
+	tests := []struct {
+		name     string
+		args     map[string]*structpb.Value
+		changed  bool
+		jobTitle *string
+	}{
+		{
+			name: "missing and empty arguments keep the current values",
+			args: map[string]*structpb.Value{
+				"job_title":  structpb.NewStringValue(""),
+				"first_name": structpb.NewStringValue("Ada"),
+			},
+		},
+		{
+			name:     "a different value is sent",
+			args:     map[string]*structpb.Value{"job_title": structpb.NewStringValue("Manager")},
+			changed:  true,
+			jobTitle: ptr("Manager"),
+		},
+		{
+			name:     "null clears the attribute",
+			args:     map[string]*structpb.Value{"job_title": structpb.NewNullValue()},
+			changed:  true,
+			jobTitle: ptr(""),
+		},
+		{
+			name: "null on an attribute that is already empty changes nothing",
+			args: map[string]*structpb.Value{"city": structpb.NewNullValue()},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			update, changed := diffUserProfile(user, &structpb.Struct{Fields: tt.args})
+			if changed != tt.changed {
+				t.Fatalf("changed = %v, want %v", changed, tt.changed)
+			}
+			if (update.JobTitle == nil) != (tt.jobTitle == nil) || (update.JobTitle != nil && *update.JobTitle != *tt.jobTitle) {
+				t.Fatalf("job title update = %v, want %v", update.JobTitle, tt.jobTitle)
+			}
+			if update.FirstName != nil {
+				t.Fatalf("first name was sent although it did not change")
+			}
+		})
+	}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
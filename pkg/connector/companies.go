@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 	"strconv"
+	"strings"
+	"sync"
 
 	"github.com/conductorone/baton-procore/pkg/client"
 	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
@@ -17,7 +19,42 @@ import (
 const companyMembership = "member"
 
 type companyBuilder struct {
-	client *client.Client
+	client        *client.Client
+	companyFilter *companyFilter
+}
+
+// companyFilter limits the companies the connector works with to the configured ones.
+type companyFilter struct {
+	include map[string]struct{}
+	exclude map[string]struct{}
+}
+
+func newCompanyFilter(include, exclude []string) *companyFilter {
+	toSet := func(ids []string) map[string]struct{} {
+		set := make(map[string]struct{}, len(ids))
+		for _, id := range ids {
+			if id = strings.TrimSpace(id); id != "" {
+				set[id] = struct{}{}
+			}
+		}
+		return set
+	}
+	return &companyFilter{
+		include: toSet(include),
+		exclude: toSet(exclude),
+	}
+}
+
+// allows reports whether the company is in the include list, when there is one, and not in the exclude list.
+func (f *companyFilter) allows(companyId string) bool {
+	if _, ok := f.exclude[companyId]; ok {
+		return false
+	}
+	if len(f.include) == 0 {
+		return true
+	}
+	_, ok := f.include[companyId]
+	return ok
 }
 
 func (o *companyBuilder) ResourceType(ctx context.Context) *v2.ResourceType {
@@ -38,6 +75,8 @@ func companyResource(company client.Company) (*v2.Resource, error) {
 		resourceSdk.WithAnnotation(
 			&v2.ChildResourceType{ResourceTypeId: projectResourceType.Id},
 			&v2.ChildResourceType{ResourceTypeId: userResourceType.Id},
+			&v2.ChildResourceType{ResourceTypeId: companyPermissionTemplateResourceType.Id},
+			&v2.ChildResourceType{ResourceTypeId: vendorResourceType.Id},
 		),
 	)
 }
@@ -61,6 +100,9 @@ func (o *companyBuilder) List(ctx context.Context, parentResourceID *v2.Resource
 
 	rv := make([]*v2.Resource, 0, len(companies))
 	for _, company := range companies {
+		if !o.companyFilter.allows(strconv.FormatInt(company.Id, 10)) {
+			continue
+		}
 		resource, err := companyResource(company)
 		if err != nil {
 			return nil, "", nil, fmt.Errorf("baton-procore: error converting company to resource: %w", err)
@@ -124,8 +166,62 @@ func (o *companyBuilder) Grants(ctx context.Context, resource *v2.Resource, pTok
 	return rv, nextPage, annotations, nil
 }
 
-func newCompanyBuilder(client *client.Client) *companyBuilder {
+// listAllCompanies pages through every company the service account is installed in and the filter allows.
+func listAllCompanies(ctx context.Context, c *client.Client, filter *companyFilter) ([]client.Company, error) {
+	var rv []client.Company
+	page := 1
+	for {
+		companies, res, _, err := c.GetCompanies(ctx, page)
+		if err != nil {
+			return nil, fmt.Errorf("baton-procore: error getting companies: %w", err)
+		}
+		for _, company := range companies {
+			if filter.allows(strconv.FormatInt(company.Id, 10)) {
+				rv = append(rv, company)
+			}
+		}
+		if !client.HasNextPage(res) {
+			return rv, nil
+		}
+		page++
+	}
+}
+
+// companyList keeps the allowed companies for the life of the connector, so that serving the metadata
+// does not list them again on every call. A failed listing is not kept and is retried on the next call.
+type companyList struct {
+	client        *client.Client
+	companyFilter *companyFilter
+	mu            sync.Mutex
+	companies     []client.Company
+	loaded        bool
+}
+
+func newCompanyList(client *client.Client, companyFilter *companyFilter) *companyList {
+	return &companyList{
+		client:        client,
+		companyFilter: companyFilter,
+	}
+}
+
+func (c *companyList) get(ctx context.Context) ([]client.Company, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.loaded {
+		return c.companies, nil
+	}
+	companies, err := listAllCompanies(ctx, c.client, c.companyFilter)
+	if err != nil {
+		return nil, err
+	}
+	c.companies = companies
+	c.loaded = true
+	return companies, nil
+}
+
+func newCompanyBuilder(client *client.Client, companyFilter *companyFilter) *companyBuilder {
 	return &companyBuilder{
-		client: client,
+		client:        client,
+		companyFilter: companyFilter,
 	}
 }
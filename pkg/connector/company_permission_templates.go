@@ -0,0 +1,257 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/conductorone/baton-procore/pkg/client"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	"github.com/conductorone/baton-sdk/pkg/types/entitlement"
+	"github.com/conductorone/baton-sdk/pkg/types/grant"
+	resourceSdk "github.com/conductorone/baton-sdk/pkg/types/resource"
+)
+
+const permissionTemplateAssignment = "assigned"
+
+type companyPermissionTemplateBuilder struct {
+	client *client.Client
+	// defaultTemplate is the name or ID of the template users fall back to on revoke.
+	defaultTemplate string
+}
+
+func getRoleCompanyId(resource *v2.Resource) (string, error) {
+	roleTrait, err := resourceSdk.GetRoleTrait(resource)
+	if err != nil {
+		return "", fmt.Errorf("baton-procore: error getting role traits: %w", err)
+	}
+	companyId, ok := resourceSdk.GetProfileStringValue(roleTrait.GetProfile(), "company_id")
+	if !ok {
+		return "", fmt.Errorf("baton-procore: company_id not found in %s resource profile", resource.Id.ResourceType)
+	}
+	return companyId, nil
+}
+
+func (o *companyPermissionTemplateBuilder) ResourceType(ctx context.Context) *v2.ResourceType {
+	return companyPermissionTemplateResourceType
+}
+
+func companyPermissionTemplateResource(companyId string, template client.PermissionTemplate, parentResourceID *v2.ResourceId) (*v2.Resource, error) {
+	profile := map[string]any{
+		"company_id": companyId,
+		"type":       template.Type,
+	}
+	return resourceSdk.NewRoleResource(
+		template.Name,
+		companyPermissionTemplateResourceType,
+		template.Id,
+		[]resourceSdk.RoleTraitOption{
+			resourceSdk.WithRoleProfile(profile),
+		},
+		resourceSdk.WithParentResourceID(parentResourceID),
+	)
+}
+
+func (o *companyPermissionTemplateBuilder) List(ctx context.Context, parentResourceID *v2.ResourceId, pToken *pagination.Token) ([]*v2.Resource, string, annotations.Annotations, error) {
+	if parentResourceID == nil {
+		return nil, "", nil, nil
+	}
+
+	page := 1
+	var err error
+	if pToken.Token != "" {
+		page, err = strconv.Atoi(pToken.Token)
+		if err != nil {
+			return nil, "", nil, fmt.Errorf("baton-procore: failed to parse page token: %w", err)
+		}
+	}
+
+	var annotations annotations.Annotations
+	templates, res, rateLimitDesc, err := o.client.GetCompanyPermissionTemplates(ctx, parentResourceID.Resource, page)
+	if err != nil {
+		return nil, "", nil, fmt.Errorf("baton-procore: error getting company permission templates: %w", err)
+	}
+	annotations = *annotations.WithRateLimiting(rateLimitDesc)
+
+	rv := make([]*v2.Resource, 0, len(templates))
+	for _, template := range templates {
+		resource, err := companyPermissionTemplateResource(parentResourceID.Resource, template, parentResourceID)
+		if err != nil {
+			return nil, "", nil, fmt.Errorf("baton-procore: error converting company permission template to resource: %w", err)
+		}
+		rv = append(rv, resource)
+	}
+
+	var nextPage string
+	if client.HasNextPage(res) {
+		nextPage = strconv.Itoa(page + 1)
+	}
+	return rv, nextPage, annotations, nil
+}
+
+func (o *companyPermissionTemplateBuilder) Entitlements(_ context.Context, resource *v2.Resource, _ *pagination.Token) ([]*v2.Entitlement, string, annotations.Annotations, error) {
+	return []*v2.Entitlement{
+		entitlement.NewAssignmentEntitlement(
+			resource,
+			permissionTemplateAssignment,
+			entitlement.WithGrantableTo(userResourceType),
+			entitlement.WithDescription(fmt.Sprintf("Assigned the %s company permission template", resource.DisplayName)),
+			entitlement.WithDisplayName(fmt.Sprintf("%s company permission template", resource.DisplayName)),
+		),
+	}, "", nil, nil
+}
+
+// Grants pages through the company users and emits a grant for every user whose
+// company permission template matches the resource.
+func (o *companyPermissionTemplateBuilder) Grants(ctx context.Context, resource *v2.Resource, pToken *pagination.Token) ([]*v2.Grant, string, annotations.Annotations, error) {
+	page := 1
+	var err error
+	if pToken.Token != "" {
+		page, err = strconv.Atoi(pToken.Token)
+		if err != nil {
+			return nil, "", nil, fmt.Errorf("baton-procore: failed to parse page token: %w", err)
+		}
+	}
+
+	companyId, err := getRoleCompanyId(resource)
+	if err != nil {
+		return nil, "", nil, fmt.Errorf("baton-procore: error getting company id from company permission template resource: %w", err)
+	}
+
+	var annotations annotations.Annotations
+	users, res, rateLimitDesc, err := o.client.GetCompanyUsers(ctx, companyId, page)
+	if err != nil {
+		return nil, "", nil, fmt.Errorf("baton-procore: error getting users: %w", err)
+	}
+	annotations = *annotations.WithRateLimiting(rateLimitDesc)
+
+	rv := make([]*v2.Grant, 0)
+	for _, user := range users {
+		if strconv.Itoa(user.CompanyPermissionTemplate.Id) != resource.Id.Resource {
+			continue
+		}
+		principalID, err := resourceSdk.NewResourceID(userResourceType, user.Id)
+		if err != nil {
+			return nil, "", nil, fmt.Errorf("baton-procore: failed to create user resource ID: %w", err)
+		}
+		rv = append(rv, grant.NewGrant(
+			resource,
+			permissionTemplateAssignment,
+			principalID,
+		))
+	}
+
+	var nextPage string
+	if client.HasNextPage(res) {
+		nextPage = strconv.Itoa(page + 1)
+	}
+	return rv, nextPage, annotations, nil
+}
+
+func (o *companyPermissionTemplateBuilder) Grant(ctx context.Context, principal *v2.Resource, entitlement *v2.Entitlement) (annotations.Annotations, error) {
+	companyId, err := getRoleCompanyId(entitlement.Resource)
+	if err != nil {
+		return nil, fmt.Errorf("baton-procore: error getting company id from company permission template resource: %w", err)
+	}
+	templateId, err := strconv.Atoi(entitlement.Resource.Id.Resource)
+	if err != nil {
+		return nil, fmt.Errorf("baton-procore: failed to parse company permission template id: %w", err)
+	}
+	userId, err := strconv.Atoi(principal.Id.Resource)
+	if err != nil {
+		return nil, fmt.Errorf("baton-procore: failed to parse user id from grant principal: %w", err)
+	}
+
+	user, _, err := o.client.Uncached().GetCompanyUser(ctx, companyId, userId)
+	if err != nil {
+		return nil, fmt.Errorf("baton-procore: error getting company user: %w", err)
+	}
+	if user.CompanyPermissionTemplate.Id == templateId {
+		return annotations.New(&v2.GrantAlreadyExists{}), nil
+	}
+
+	err = o.client.SetCompanyPermissionTemplate(ctx, companyId, userId, templateId)
+	if err != nil {
+		return nil, fmt.Errorf("baton-procore: error setting company permission template: %w", err)
+	}
+
+	return nil, nil
+}
+
+// Revoke moves the user back to the configured default template, since a company user
+// always has exactly one company permission template.
+func (o *companyPermissionTemplateBuilder) Revoke(ctx context.Context, grant *v2.Grant) (annotations.Annotations, error) {
+	entitlement := grant.Entitlement
+	companyId, err := getRoleCompanyId(entitlement.Resource)
+	if err != nil {
+		return nil, fmt.Errorf("baton-procore: error getting company id from company permission template resource: %w", err)
+	}
+	templateId, err := strconv.Atoi(entitlement.Resource.Id.Resource)
+	if err != nil {
+		return nil, fmt.Errorf("baton-procore: failed to parse company permission template id: %w", err)
+	}
+	userId, err := strconv.Atoi(grant.Principal.Id.Resource)
+	if err != nil {
+		return nil, fmt.Errorf("baton-procore: failed to parse user id from grant principal: %w", err)
+	}
+
+	user, _, err := o.client.Uncached().GetCompanyUser(ctx, companyId, userId)
+	if err != nil {
+		return nil, fmt.Errorf("baton-procore: error getting company user: %w", err)
+	}
+	if user.CompanyPermissionTemplate.Id != templateId {
+		return annotations.New(&v2.GrantAlreadyRevoked{}), nil
+	}
+
+	defaultTemplateId, err := o.findDefaultTemplate(ctx, companyId)
+	if err != nil {
+		return nil, err
+	}
+	if defaultTemplateId == templateId {
+		return nil, fmt.Errorf("baton-procore: cannot revoke the default company permission template %q", o.defaultTemplate)
+	}
+
+	err = o.client.SetCompanyPermissionTemplate(ctx, companyId, userId, defaultTemplateId)
+	if err != nil {
+		return nil, fmt.Errorf("baton-procore: error setting company permission template: %w", err)
+	}
+
+	return nil, nil
+}
+
+// findDefaultTemplate resolves the configured default template, matching by ID or by name,
+// against the templates of the given company.
+func (o *companyPermissionTemplateBuilder) findDefaultTemplate(ctx context.Context, companyId string) (int, error) {
+	if o.defaultTemplate == "" {
+		return 0, fmt.Errorf("baton-procore: a default company permission template must be configured to revoke company permission templates")
+	}
+
+	page := 1
+	for {
+		templates, res, _, err := o.client.GetCompanyPermissionTemplates(ctx, companyId, page)
+		if err != nil {
+			return 0, fmt.Errorf("baton-procore: error getting company permission templates: %w", err)
+		}
+		for _, template := range templates {
+			if strconv.Itoa(template.Id) == o.defaultTemplate || strings.EqualFold(template.Name, o.defaultTemplate) {
+				return template.Id, nil
+			}
+		}
+		if !client.HasNextPage(res) {
+			break
+		}
+		page++
+	}
+
+	return 0, fmt.Errorf("baton-procore: default company permission template %q not found in company %s", o.defaultTemplate, companyId)
+}
+
+func newCompanyPermissionTemplateBuilder(client *client.Client, defaultTemplate string) *companyPermissionTemplateBuilder {
+	return &companyPermissionTemplateBuilder{
+		client:          client,
+		defaultTemplate: defaultTemplate,
+	}
+}
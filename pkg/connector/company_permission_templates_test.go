@@ -0,0 +1,89 @@
+package connector
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/conductorone/baton-procore/pkg/client"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/types/grant"
+	resourceSdk "github.com/conductorone/baton-sdk/pkg/types/resource"
+)
+
+func TestCompanyPermissionTemplateGrantThenRevoke(t *testing.T) {
+	ctx := context.Background()
+	const (
+		standardId = 10
+		adminId    = 20
+	)
+	templates := []client.PermissionTemplate{
+		{Id: standardId, Name: "Standard"},
+		{Id: adminId, Name: "Admin"},
+	}
+
+	var mu sync.Mutex
+	current := standardId
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1.3/companies/1/users/7", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		writeJSON(w, client.User{Id: 7, CompanyPermissionTemplate: client.PermissionTemplate{Id: current}})
+	})
+	mux.HandleFunc("PATCH /v1.3/companies/1/users/7", func(w http.ResponseWriter, r *http.Request) {
+		var body client.UpdateUserBody
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		current = *body.User.CompanyPermissionTemplateId
+		writeJSON(w, client.User{Id: 7, CompanyPermissionTemplate: client.PermissionTemplate{Id: current}})
+	})
+	mux.HandleFunc("GET /v1.0/companies/1/permission_templates", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, templates)
+	})
+	c := newTestClient(t, mux)
+	builder := newCompanyPermissionTemplateBuilder(c, "Standard")
+
+	// the sync reads the user through the cache before any provisioning happens
+	if _, _, err := c.GetCompanyUser(ctx, "1", 7); err != nil {
+		t.Fatalf("reading user: %v", err)
+	}
+
+	parent := &v2.ResourceId{ResourceType: companyResourceType.Id, Resource: "1"}
+	admin, err := companyPermissionTemplateResource("1", templates[1], parent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	principal, err := resourceSdk.NewResourceID(userResourceType, 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ent := &v2.Entitlement{Id: "admin:assigned", Resource: admin}
+
+	annos, err := builder.Grant(ctx, &v2.Resource{Id: principal}, ent)
+	if err != nil {
+		t.Fatalf("grant: %v", err)
+	}
+	if annos.Contains(&v2.GrantAlreadyExists{}) {
+		t.Fatal("grant reported the template as already assigned")
+	}
+	if current != adminId {
+		t.Fatalf("after grant the template is %d, want %d", current, adminId)
+	}
+
+	annos, err = builder.Revoke(ctx, grant.NewGrant(admin, permissionTemplateAssignment, principal))
+	if err != nil {
+		t.Fatalf("revoke: %v", err)
+	}
+	if annos.Contains(&v2.GrantAlreadyRevoked{}) {
+		t.Fatal("revoke read a stale template and reported it as already revoked")
+	}
+	if current != standardId {
+		t.Fatalf("after revoke the template is %d, want the default %d", current, standardId)
+	}
+}
@@ -0,0 +1,252 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/conductorone/baton-procore/pkg/client"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// companyUserIndex is the company directory of one company, as listed by userBuilder.List.
+type companyUserIndex struct {
+	byEmail map[string]int
+	users   map[int]client.User
+}
+
+// projectUserIndex is the directory of one project, keyed by project user id.
+type projectUserIndex map[int]client.User
+
+// companyUsers correlates project directory entries with the company user resources, in both
+// directions. Project endpoints neither return nor take the company user id, so entries are matched
+// on their email address within the company that owns the project. Each company is indexed
+// separately because a user who belongs to several companies has a company user record in each of them.
+type companyUsers struct {
+	client        *client.Client
+	companyFilter *companyFilter
+	mu            sync.Mutex
+	m             map[string]*companyUserIndex
+
+	membershipsMu sync.Mutex
+	// memberships maps user ids to their company user records, in company listing order.
+	memberships map[int][]companyUserRecord
+
+	projectsMu sync.Mutex
+	// projects maps project ids to their directory.
+	projects map[string]projectUserIndex
+}
+
+func newCompanyUsers(client *client.Client, companyFilter *companyFilter) *companyUsers {
+	return &companyUsers{
+		client:        client,
+		companyFilter: companyFilter,
+		m:             make(map[string]*companyUserIndex),
+		projects:      make(map[string]projectUserIndex),
+	}
+}
+
+// resolve returns the id of the company user resource matching the project user by email. It reports
+// false when the user has no email address or is not part of the company directory, in which case no
+// user resource can be told apart to attach a grant to.
+func (c *companyUsers) resolve(ctx context.Context, companyId string, projectUser client.User) (int, bool, error) {
+	index, err := c.index(ctx, companyId)
+	if err != nil {
+		return 0, false, err
+	}
+
+	// the ids are no match, project user ids and company user ids are separate id spaces
+	id, ok := index.byEmail[strings.ToLower(strings.TrimSpace(projectUser.EmailAddress))]
+	return id, ok, nil
+}
+
+func (c *companyUsers) index(ctx context.Context, companyId string) (*companyUserIndex, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if index, ok := c.m[companyId]; ok {
+		return index, nil
+	}
+
+	index := &companyUserIndex{
+		byEmail: make(map[string]int),
+		users:   make(map[int]client.User),
+	}
+	page := 1
+	for {
+		users, res, _, err := c.client.GetCompanyUsers(ctx, companyId, page)
+		if err != nil {
+			return nil, fmt.Errorf("baton-procore: error getting company users: %w", err)
+		}
+		for _, user := range users {
+			index.users[user.Id] = user
+			if email := strings.ToLower(strings.TrimSpace(user.EmailAddress)); email != "" {
+				index.byEmail[email] = user.Id
+			}
+		}
+		if !client.HasNextPage(res) {
+			break
+		}
+		page++
+	}
+
+	c.m[companyId] = index
+	return index, nil
+}
+
+// companiesOf returns the company user records of the user, one per company they belong to, in the
+// order the companies are listed. Every company directory is indexed on the first call.
+func (c *companyUsers) companiesOf(ctx context.Context, userId int) ([]companyUserRecord, error) {
+	c.membershipsMu.Lock()
+	defer c.membershipsMu.Unlock()
+	if c.memberships == nil {
+		companies, err := listAllCompanies(ctx, c.client, c.companyFilter)
+		if err != nil {
+			return nil, err
+		}
+		memberships := make(map[int][]companyUserRecord)
+		for _, company := range companies {
+			companyId := strconv.FormatInt(company.Id, 10)
+			index, err := c.index(ctx, companyId)
+			if err != nil {
+				return nil, err
+			}
+			for id, user := range index.users {
+				memberships[id] = append(memberships[id], companyUserRecord{companyId: companyId, user: &user})
+			}
+		}
+		c.memberships = memberships
+	}
+	return c.memberships[userId], nil
+}
+
+// companiesWith is companiesOf with the record of one company replaced by the given one, which was just
+// listed. The record is appended when the user joined the company after the indexing.
+func (c *companyUsers) companiesWith(ctx context.Context, companyId string, user *client.User) ([]companyUserRecord, error) {
+	memberships, err := c.companiesOf(ctx, user.Id)
+	if err != nil {
+		return nil, err
+	}
+	record := companyUserRecord{companyId: companyId, user: user}
+	rv := slices.Clone(memberships)
+	for i, membership := range rv {
+		if membership.companyId == companyId {
+			rv[i] = record
+			return rv, nil
+		}
+	}
+	return append(rv, record), nil
+}
+
+// readMemberships reads the record of the user in every allowed company, past the HTTP cache, for the
+// provisioning calls that build or decide on a single user without indexing every company directory.
+// known is the record of one company that a write just returned, which is used instead of reading it.
+func (c *companyUsers) readMemberships(ctx context.Context, userId int, known *companyUserRecord) ([]companyUserRecord, error) {
+	companies, err := listAllCompanies(ctx, c.client, c.companyFilter)
+	if err != nil {
+		return nil, err
+	}
+
+	var memberships []companyUserRecord
+	for _, company := range companies {
+		companyId := strconv.FormatInt(company.Id, 10)
+		if known != nil && known.companyId == companyId {
+			memberships = append(memberships, *known)
+			continue
+		}
+		user, _, err := c.client.Uncached().GetCompanyUser(ctx, companyId, userId)
+		if err != nil {
+			if status.Code(err) == codes.NotFound {
+				continue
+			}
+			return nil, fmt.Errorf("baton-procore: error getting company user: %w", err)
+		}
+		memberships = append(memberships, companyUserRecord{companyId: companyId, user: user})
+	}
+	return memberships, nil
+}
+
+// reset drops every index, so they are rebuilt from fresh responses. The connector outlives a sync
+// and the SDK clears the HTTP cache once a sync ends, so reset is called as the next one starts.
+func (c *companyUsers) reset() {
+	c.mu.Lock()
+	c.m = make(map[string]*companyUserIndex)
+	c.mu.Unlock()
+
+	c.membershipsMu.Lock()
+	c.memberships = nil
+	c.membershipsMu.Unlock()
+
+	c.projectsMu.Lock()
+	c.projects = make(map[string]projectUserIndex)
+	c.projectsMu.Unlock()
+}
+
+// resolveProjectUserId returns the id of the company user resource behind a project user id, for the
+// project endpoints that only return the id. The project directory is indexed on first use.
+func (c *companyUsers) resolveProjectUserId(ctx context.Context, companyId, projectId string, projectUserId int) (int, bool, error) {
+	directory, err := c.projectDirectory(ctx, companyId, projectId)
+	if err != nil {
+		return 0, false, err
+	}
+	projectUser, ok := directory[projectUserId]
+	if !ok {
+		return 0, false, nil
+	}
+	return c.resolve(ctx, companyId, projectUser)
+}
+
+func (c *companyUsers) projectDirectory(ctx context.Context, companyId, projectId string) (projectUserIndex, error) {
+	c.projectsMu.Lock()
+	defer c.projectsMu.Unlock()
+	if directory, ok := c.projects[projectId]; ok {
+		return directory, nil
+	}
+
+	directory := make(projectUserIndex)
+	page := 1
+	for {
+		users, res, _, err := c.client.GetProjectUsers(ctx, companyId, projectId, page)
+		if err != nil {
+			return nil, fmt.Errorf("baton-procore: error getting project users: %w", err)
+		}
+		for _, user := range users {
+			directory[user.Id] = user
+		}
+		if !client.HasNextPage(res) {
+			break
+		}
+		page++
+	}
+
+	c.projects[projectId] = directory
+	return directory, nil
+}
+
+// projectUser returns the project directory entry of the company user, or nil when the user is not on
+// the project. Project scoped endpoints take the id of that entry, so every write to them goes through
+// here. The lookups skip the HTTP cache, as they decide what to provision.
+func (c *companyUsers) projectUser(ctx context.Context, companyId, projectId string, userId int) (*client.User, error) {
+	user, _, err := c.client.Uncached().GetCompanyUser(ctx, companyId, userId)
+	if err != nil {
+		return nil, fmt.Errorf("baton-procore: error getting company user: %w", err)
+	}
+	return c.findOnProject(ctx, companyId, projectId, *user)
+}
+
+// findOnProject is projectUser for a company user record that was just read.
+func (c *companyUsers) findOnProject(ctx context.Context, companyId, projectId string, user client.User) (*client.User, error) {
+	email := strings.TrimSpace(user.EmailAddress)
+	if email == "" {
+		return nil, fmt.Errorf("baton-procore: company user %d has no email address to find them on project %s with", user.Id, projectId)
+	}
+
+	projectUser, err := c.client.Uncached().FindProjectUserByEmail(ctx, companyId, projectId, email)
+	if err != nil {
+		return nil, fmt.Errorf("baton-procore: error searching project users: %w", err)
+	}
+	return projectUser, nil
+}
@@ -0,0 +1,76 @@
+package connector
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"testing"
+
+	"github.com/conductorone/baton-procore/pkg/client"
+)
+
+func TestProjectUserIdsDoNotMatchCompanyUserIds(t *testing.T) {
+	ctx := context.Background()
+
+	// project user 8 is Ada, while company user 8 is Bob, an unrelated person
+	var projectCalls atomic.Int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1.3/companies/1/users", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, []client.User{
+			{Id: 7, EmailAddress: "ada@example.com"},
+			{Id: 8, EmailAddress: "bob@example.com"},
+		})
+	})
+	mux.HandleFunc("GET /v1.3/companies/1/users/9", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, client.User{Id: 9})
+	})
+	mux.HandleFunc("/v1.0/projects/", func(w http.ResponseWriter, r *http.Request) {
+		projectCalls.Add(1)
+		writeJSON(w, client.User{Id: 9})
+	})
+	c := newTestClient(t, mux)
+	users := newCompanyUsers(c, newCompanyFilter(nil, nil))
+
+	tests := []struct {
+		name        string
+		projectUser client.User
+		wantId      int
+		wantOk      bool
+	}{
+		{
+			name:        "matched by email",
+			projectUser: client.User{Id: 8, EmailAddress: "Ada@example.com"},
+			wantId:      7,
+			wantOk:      true,
+		},
+		{
+			name:        "no email is not matched on the colliding id",
+			projectUser: client.User{Id: 8},
+		},
+		{
+			name:        "an unknown email is not matched on the colliding id",
+			projectUser: client.User{Id: 8, EmailAddress: "carol@example.com"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id, ok, err := users.resolve(ctx, "1", tt.projectUser)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if id != tt.wantId || ok != tt.wantOk {
+				t.Fatalf("resolve = %d, %v, want %d, %v", id, ok, tt.wantId, tt.wantOk)
+			}
+		})
+	}
+
+	t.Run("provisioning a user without an email fails", func(t *testing.T) {
+		projectUser, err := users.projectUser(ctx, "1", "100", 9)
+		if err == nil {
+			t.Fatalf("projectUser = %v, want an error", projectUser)
+		}
+		if projectCalls.Load() != 0 {
+			t.Fatal("the company user id was sent to a project endpoint")
+		}
+	})
+}
@@ -2,29 +2,69 @@ package connector
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"strconv"
+	"strings"
 
 	"github.com/conductorone/baton-procore/pkg/client"
 	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
 	"github.com/conductorone/baton-sdk/pkg/annotations"
 	"github.com/conductorone/baton-sdk/pkg/connectorbuilder"
+	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
+	"go.uber.org/zap"
+	"golang.org/x/oauth2"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type Connector struct {
 	client *client.Client
-	// cache is needed because project users ids are different from company users ids, even if
-	// they are the same user.
-	//	email: company_id
-	usersCache map[string]int
+	// companyUsers maps between project user ids and company user ids, which differ for the same user:
+	// grants read from a project are mapped to company users, and writes to a project to project users.
+	companyUsers *companyUsers
+	// projectCompanies is shared by the builders of project children, which only receive the project id.
+	projectCompanies *projectCompanies
+	// defaultCompanyPermissionTemplate is the name or ID of the template users get when theirs is revoked.
+	defaultCompanyPermissionTemplate string
+	// removeFromProjectsOnDelete makes user deletion also remove the user from every project.
+	removeFromProjectsOnDelete bool
+	// companyFilter limits syncing and provisioning to the configured companies.
+	companyFilter *companyFilter
+	// companies are the allowed companies offered in the account creation schema.
+	companies *companyList
+	// projectFilter limits the synced projects, and with them their directories.
+	projectFilter ProjectFilter
+}
+
+// Options are the optional settings of the connector. The zero value syncs every company and project
+// of the production environment.
+type Options struct {
+	// DefaultCompanyPermissionTemplate is the name or ID of the template users get when theirs is revoked.
+	DefaultCompanyPermissionTemplate string
+	// RemoveFromProjectsOnDelete makes user deletion also remove the user from every project.
+	RemoveFromProjectsOnDelete bool
+	// IncludeCompanyIds and ExcludeCompanyIds limit the synced and provisioned companies.
+	IncludeCompanyIds []string
+	ExcludeCompanyIds []string
+	ProjectFilter     ProjectFilter
+	// RetryMaxAttempts is how many times a retryable request is sent, client.DefaultMaxAttempts when 0.
+	RetryMaxAttempts int
+	// Environment holds the Procore URLs; empty URLs keep the production ones.
+	Environment client.Environment
 }
 
 // ResourceSyncers returns a ResourceSyncer for each resource type that should be synced from the upstream service.
 func (d *Connector) ResourceSyncers(ctx context.Context) []connectorbuilder.ResourceSyncer {
 	return []connectorbuilder.ResourceSyncer{
-		newCompanyBuilder(d.client),
-		newProjectBuilder(d.client),
-		newUserBuilder(d.client),
+		newCompanyBuilder(d.client, d.companyFilter),
+		newProjectBuilder(d.client, d.projectCompanies, d.companyUsers, d.projectFilter),
+		newUserBuilder(d.client, d.companyUsers, d.companyFilter, d.removeFromProjectsOnDelete),
+		newCompanyPermissionTemplateBuilder(d.client, d.defaultCompanyPermissionTemplate),
+		newVendorBuilder(d.client),
+		newDistributionGroupBuilder(d.client, d.projectCompanies, d.companyUsers),
+		newProjectRoleBuilder(d.client, d.projectCompanies, d.companyUsers),
 	}
 }
 
@@ -36,120 +76,245 @@ func (d *Connector) Asset(ctx context.Context, asset *v2.AssetRef) (string, io.R
 
 // Metadata returns metadata about the connector.
 func (d *Connector) Metadata(ctx context.Context) (*v2.ConnectorMetadata, error) {
+	l := ctxzap.Extract(ctx)
+
+	// the company choices are best effort, metadata is also served without valid credentials
+	companies, err := d.companies.get(ctx)
+	if err != nil {
+		l.Warn("baton-procore: unable to list companies for the account creation schema", zap.Error(err))
+	}
+
 	return &v2.ConnectorMetadata{
-		DisplayName: "Baton Connector",
-		Description: "This connector allows you to sync data from Procore.",
-		AccountCreationSchema: &v2.ConnectorAccountCreationSchema{
-			FieldMap: map[string]*v2.ConnectorAccountCreationSchema_Field{
-				"companyId": {
-					DisplayName: "Company ID",
-					Required:    true,
-					Description: "The ID of the company to which the user belongs.",
-					Field: &v2.ConnectorAccountCreationSchema_Field_StringField{
-						StringField: &v2.ConnectorAccountCreationSchema_StringField{},
-					},
-					Placeholder: "Company ID",
-					Order:       1,
+		DisplayName:           "Baton Connector",
+		Description:           "This connector allows you to sync data from Procore.",
+		AccountCreationSchema: accountCreationSchema(companies),
+	}, nil
+}
+
+// accountCreationSchema lists the visible companies in the company field, and defaults to the
+// only company when the service account is installed in a single one. The SDK's string fields
+// have no list of choices, so the companies are listed in the description and CreateAccount
+// rejects any other company.
+func accountCreationSchema(companies []client.Company) *v2.ConnectorAccountCreationSchema {
+	companyField := &v2.ConnectorAccountCreationSchema_Field{
+		DisplayName: "Company ID",
+		Required:    true,
+		Description: "The ID of the company to which the user belongs.",
+		Field: &v2.ConnectorAccountCreationSchema_Field_StringField{
+			StringField: &v2.ConnectorAccountCreationSchema_StringField{},
+		},
+		Placeholder: "Company ID",
+		Order:       1,
+	}
+	if len(companies) > 0 {
+		choices := make([]string, 0, len(companies))
+		for _, company := range companies {
+			choices = append(choices, fmt.Sprintf("%s (%d)", company.Name, company.Id))
+		}
+		companyField.Description = fmt.Sprintf("The ID of the company to which the user belongs. One of: %s.", strings.Join(choices, ", "))
+	}
+	if len(companies) == 1 {
+		companyId := strconv.FormatInt(companies[0].Id, 10)
+		companyField.GetStringField().DefaultValue = &companyId
+	}
+
+	return &v2.ConnectorAccountCreationSchema{
+		FieldMap: map[string]*v2.ConnectorAccountCreationSchema_Field{
+			"companyId": companyField,
+			"email": {
+				DisplayName: "Email",
+				Required:    true,
+				Description: "The email address of the user.",
+				Field: &v2.ConnectorAccountCreationSchema_Field_StringField{
+					StringField: &v2.ConnectorAccountCreationSchema_StringField{},
 				},
-				"email": {
-					DisplayName: "Email",
-					Required:    true,
-					Description: "The email address of the user.",
-					Field: &v2.ConnectorAccountCreationSchema_Field_StringField{
-						StringField: &v2.ConnectorAccountCreationSchema_StringField{},
-					},
-					Placeholder: "Email",
-					Order:       2,
+				Placeholder: "Email",
+				Order:       2,
+			},
+			"lastName": {
+				DisplayName: "User's Last Name",
+				Required:    true,
+				Description: "The last name of the user.",
+				Field: &v2.ConnectorAccountCreationSchema_Field_StringField{
+					StringField: &v2.ConnectorAccountCreationSchema_StringField{},
 				},
-				"lastName": {
-					DisplayName: "User's Last Name",
-					Required:    true,
-					Description: "The last name of the user.",
-					Field: &v2.ConnectorAccountCreationSchema_Field_StringField{
-						StringField: &v2.ConnectorAccountCreationSchema_StringField{},
-					},
-					Placeholder: "Last Name",
-					Order:       3,
+				Placeholder: "Last Name",
+				Order:       3,
+			},
+			"firstName": {
+				DisplayName: "User's First Name",
+				Required:    false,
+				Description: "The first name of the user.",
+				Field: &v2.ConnectorAccountCreationSchema_Field_StringField{
+					StringField: &v2.ConnectorAccountCreationSchema_StringField{},
 				},
-				"firstName": {
-					DisplayName: "User's First Name",
-					Required:    false,
-					Description: "The first name of the user.",
-					Field: &v2.ConnectorAccountCreationSchema_Field_StringField{
-						StringField: &v2.ConnectorAccountCreationSchema_StringField{},
-					},
-					Placeholder: "First Name",
-					Order:       4,
+				Placeholder: "First Name",
+				Order:       4,
+			},
+			"city": {
+				DisplayName: "User's City",
+				Required:    false,
+				Description: "The city where the user resides.",
+				Field: &v2.ConnectorAccountCreationSchema_Field_StringField{
+					StringField: &v2.ConnectorAccountCreationSchema_StringField{},
 				},
-				"city": {
-					DisplayName: "User's City",
-					Required:    false,
-					Description: "The city where the user resides.",
-					Field: &v2.ConnectorAccountCreationSchema_Field_StringField{
-						StringField: &v2.ConnectorAccountCreationSchema_StringField{},
-					},
-					Placeholder: "City",
-					Order:       5,
+				Placeholder: "City",
+				Order:       5,
+			},
+			"address": {
+				DisplayName: "User's Address",
+				Required:    false,
+				Description: "The address of the user.",
+				Field: &v2.ConnectorAccountCreationSchema_Field_StringField{
+					StringField: &v2.ConnectorAccountCreationSchema_StringField{},
 				},
-				"address": {
-					DisplayName: "User's Address",
-					Required:    false,
-					Description: "The address of the user.",
-					Field: &v2.ConnectorAccountCreationSchema_Field_StringField{
-						StringField: &v2.ConnectorAccountCreationSchema_StringField{},
-					},
-					Placeholder: "Address",
-					Order:       6,
+				Placeholder: "Address",
+				Order:       6,
+			},
+			"jobTitle": {
+				DisplayName: "User's Job Title",
+				Required:    false,
+				Description: "The job title of the user.",
+				Field: &v2.ConnectorAccountCreationSchema_Field_StringField{
+					StringField: &v2.ConnectorAccountCreationSchema_StringField{},
 				},
-				"jobTitle": {
-					DisplayName: "User's Job Title",
-					Required:    false,
-					Description: "The job title of the user.",
-					Field: &v2.ConnectorAccountCreationSchema_Field_StringField{
-						StringField: &v2.ConnectorAccountCreationSchema_StringField{},
-					},
-					Placeholder: "Job Title",
-					Order:       7,
+				Placeholder: "Job Title",
+				Order:       7,
+			},
+			"isEmployee": {
+				DisplayName: "Is Employee",
+				Required:    false,
+				Description: "Indicates if the user is an employee.",
+				Field: &v2.ConnectorAccountCreationSchema_Field_BoolField{
+					BoolField: &v2.ConnectorAccountCreationSchema_BoolField{},
 				},
-				"isEmployee": {
-					DisplayName: "Is Employee",
-					Required:    false,
-					Description: "Indicates if the user is an employee.",
-					Field: &v2.ConnectorAccountCreationSchema_Field_StringField{
-						StringField: &v2.ConnectorAccountCreationSchema_StringField{},
-					},
-					Placeholder: "Is Employee",
-					Order:       8,
+				Placeholder: "Is Employee",
+				Order:       8,
+			},
+			"isActive": {
+				DisplayName: "Is Active",
+				Required:    false,
+				Description: "Indicates if the user is currently active.",
+				Field: &v2.ConnectorAccountCreationSchema_Field_BoolField{
+					BoolField: &v2.ConnectorAccountCreationSchema_BoolField{},
 				},
-				"isActive": {
-					DisplayName: "Is Active",
-					Required:    false,
-					Description: "Indicates if the user is currently active.",
-					Field: &v2.ConnectorAccountCreationSchema_Field_StringField{
-						StringField: &v2.ConnectorAccountCreationSchema_StringField{},
-					},
-					Placeholder: "Is Active",
-					Order:       9,
+				Placeholder: "Is Active",
+				Order:       9,
+			},
+			"companyPermissionTemplateId": {
+				DisplayName: "Company Permission Template ID",
+				Required:    false,
+				Description: "The ID of the company permission template assigned to the user.",
+				Field: &v2.ConnectorAccountCreationSchema_Field_StringField{
+					StringField: &v2.ConnectorAccountCreationSchema_StringField{},
 				},
+				Placeholder: "Company Permission Template ID",
+				Order:       10,
+			},
+			"vendorId": {
+				DisplayName: "Vendor ID",
+				Required:    false,
+				Description: "The ID of the vendor (directory company) the user works for.",
+				Field: &v2.ConnectorAccountCreationSchema_Field_StringField{
+					StringField: &v2.ConnectorAccountCreationSchema_StringField{},
+				},
+				Placeholder: "Vendor ID",
+				Order:       11,
+			},
+			"projects": {
+				DisplayName: "Projects",
+				Required:    false,
+				Description: "The projects the user is added to, as project IDs optionally followed by a project permission template ID, e.g. 1234 or 1234:5678.",
+				Field: &v2.ConnectorAccountCreationSchema_Field_StringListField{
+					StringListField: &v2.ConnectorAccountCreationSchema_StringListField{},
+				},
+				Placeholder: "1234:5678",
+				Order:       12,
 			},
 		},
-	}, nil
+	}
 }
 
-// Validate is called to ensure that the connector is properly configured. It should exercise any API credentials
-// to be sure that they are valid.
+// Validate checks that the client credentials are valid and that every company the app is installed in
+// lets it read the company directory and the project list, which every sync depends on.
 func (d *Connector) Validate(ctx context.Context) (annotations.Annotations, error) {
+	// every sync starts with Validate, and must not see the user indexes of the previous one
+	d.companyUsers.reset()
+
+	err := d.client.CheckToken()
+	if err != nil {
+		var retrieveErr *oauth2.RetrieveError
+		if errors.As(err, &retrieveErr) {
+			return nil, fmt.Errorf("baton-procore: invalid client credentials, check the client ID and secret: %w", err)
+		}
+		return nil, fmt.Errorf("baton-procore: could not obtain an access token: %w", err)
+	}
+
+	companies, err := d.companies.get(ctx)
+	if err != nil {
+		return nil, validationError("the list of companies", "", err)
+	}
+	if len(companies) == 0 {
+		return nil, fmt.Errorf("baton-procore: the app is not installed in any of the configured companies, install it from the company App Management page or check the company filters")
+	}
+
+	for _, company := range companies {
+		companyId := strconv.FormatInt(company.Id, 10)
+		companyName := fmt.Sprintf("%s (%d)", company.Name, company.Id)
+
+		_, _, _, err = d.client.GetCompanyUsers(ctx, companyId, 1)
+		if err != nil {
+			return nil, validationError(fmt.Sprintf("the users of company %s", companyName), "Read Only access to the company Directory tool", err)
+		}
+
+		_, _, _, err = d.client.GetProjects(ctx, companyId, 1)
+		if err != nil {
+			return nil, validationError(fmt.Sprintf("the projects of company %s", companyName), "Read Only access to the company Portfolio tool", err)
+		}
+	}
+
 	return nil, nil
 }
 
+// validationError explains a failed health check request in terms of what the app is missing.
+func validationError(target, permission string, err error) error {
+	switch status.Code(err) {
+	case codes.Unauthenticated:
+		return fmt.Errorf("baton-procore: the access token was rejected while reading %s, check the client ID and secret: %w", target, err)
+	case codes.PermissionDenied:
+		if permission == "" {
+			return fmt.Errorf("baton-procore: the app is not allowed to read %s: %w", target, err)
+		}
+		return fmt.Errorf("baton-procore: the app is not allowed to read %s, grant it %s: %w", target, permission, err)
+	case codes.NotFound:
+		return fmt.Errorf("baton-procore: could not read %s, make sure the app is installed in that company: %w", target, err)
+	default:
+		return fmt.Errorf("baton-procore: error reading %s: %w", target, err)
+	}
+}
+
 // New returns a new instance of the connector.
-func New(ctx context.Context, clientId, clientSecret string) (*Connector, error) {
-	client, err := client.New(ctx, clientId, clientSecret)
+func New(ctx context.Context, clientId, clientSecret string, options Options) (*Connector, error) {
+	client, err := client.New(
+		ctx,
+		clientId,
+		clientSecret,
+		client.WithMaxAttempts(options.RetryMaxAttempts),
+		client.WithBaseURL(options.Environment.BaseURL),
+		client.WithTokenURL(options.Environment.TokenURL),
+	)
 	if err != nil {
 		return nil, fmt.Errorf("error creating Procore client: %w", err)
 	}
+	companyFilter := newCompanyFilter(options.IncludeCompanyIds, options.ExcludeCompanyIds)
 	return &Connector{
-		client:     client,
-		usersCache: make(map[string]int),
+		client:                           client,
+		companyUsers:                     newCompanyUsers(client, companyFilter),
+		projectCompanies:                 newProjectCompanies(client, companyFilter),
+		defaultCompanyPermissionTemplate: options.DefaultCompanyPermissionTemplate,
+		removeFromProjectsOnDelete:       options.RemoveFromProjectsOnDelete,
+		companyFilter:                    companyFilter,
+		companies:                        newCompanyList(client, companyFilter),
+		projectFilter:                    options.ProjectFilter,
 	}, nil
 }
@@ -0,0 +1,249 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/conductorone/baton-procore/pkg/client"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	"github.com/conductorone/baton-sdk/pkg/types/entitlement"
+	"github.com/conductorone/baton-sdk/pkg/types/grant"
+	resourceSdk "github.com/conductorone/baton-sdk/pkg/types/resource"
+	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
+	"go.uber.org/zap"
+)
+
+const distributionGroupMembership = "member"
+
+type distributionGroupBuilder struct {
+	client           *client.Client
+	projectCompanies *projectCompanies
+	companyUsers     *companyUsers
+}
+
+func getProjectId(resource *v2.Resource) (string, error) {
+	groupTrait, err := resourceSdk.GetGroupTrait(resource)
+	if err != nil {
+		return "", fmt.Errorf("baton-procore: error getting group traits: %w", err)
+	}
+	projectId, ok := resourceSdk.GetProfileStringValue(groupTrait.GetProfile(), "project_id")
+	if !ok {
+		return "", fmt.Errorf("baton-procore: project_id not found in %s resource profile", resource.Id.ResourceType)
+	}
+	return projectId, nil
+}
+
+func (o *distributionGroupBuilder) ResourceType(ctx context.Context) *v2.ResourceType {
+	return distributionGroupResourceType
+}
+
+func distributionGroupResource(companyId string, group client.DistributionGroup, parentResourceID *v2.ResourceId) (*v2.Resource, error) {
+	profile := map[string]any{
+		"company_id": companyId,
+		"project_id": parentResourceID.Resource,
+	}
+	return resourceSdk.NewGroupResource(
+		group.Name,
+		distributionGroupResourceType,
+		group.Id,
+		[]resourceSdk.GroupTraitOption{
+			resourceSdk.WithGroupProfile(profile),
+		},
+		resourceSdk.WithParentResourceID(parentResourceID),
+	)
+}
+
+func (o *distributionGroupBuilder) List(ctx context.Context, parentResourceID *v2.ResourceId, pToken *pagination.Token) ([]*v2.Resource, string, annotations.Annotations, error) {
+	if parentResourceID == nil {
+		return nil, "", nil, nil
+	}
+
+	page := 1
+	var err error
+	if pToken.Token != "" {
+		page, err = strconv.Atoi(pToken.Token)
+		if err != nil {
+			return nil, "", nil, fmt.Errorf("baton-procore: failed to parse page token: %w", err)
+		}
+	}
+
+	companyId, err := o.projectCompanies.get(ctx, parentResourceID.Resource)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	var annotations annotations.Annotations
+	groups, res, rateLimitDesc, err := o.client.GetDistributionGroups(ctx, companyId, parentResourceID.Resource, page)
+	if err != nil {
+		if skipped, ok := skipUnavailableProject(ctx, parentResourceID.Resource, "distribution groups", err); ok {
+			return nil, "", skipped, nil
+		}
+		return nil, "", nil, fmt.Errorf("baton-procore: error getting distribution groups: %w", err)
+	}
+	annotations = *annotations.WithRateLimiting(rateLimitDesc)
+
+	rv := make([]*v2.Resource, 0, len(groups))
+	for _, group := range groups {
+		resource, err := distributionGroupResource(companyId, group, parentResourceID)
+		if err != nil {
+			return nil, "", nil, fmt.Errorf("baton-procore: error converting distribution group to resource: %w", err)
+		}
+		rv = append(rv, resource)
+	}
+
+	var nextPage string
+	if client.HasNextPage(res) {
+		nextPage = strconv.Itoa(page + 1)
+	}
+	return rv, nextPage, annotations, nil
+}
+
+func (o *distributionGroupBuilder) Entitlements(_ context.Context, resource *v2.Resource, _ *pagination.Token) ([]*v2.Entitlement, string, annotations.Annotations, error) {
+	return []*v2.Entitlement{
+		entitlement.NewAssignmentEntitlement(
+			resource,
+			distributionGroupMembership,
+			entitlement.WithGrantableTo(userResourceType),
+			entitlement.WithDescription(fmt.Sprintf("Member of %s distribution group", resource.DisplayName)),
+			entitlement.WithDisplayName(fmt.Sprintf("Member of %s distribution group", resource.DisplayName)),
+		),
+	}, "", nil, nil
+}
+
+// Grants attaches the group members to their company user resources.
+func (o *distributionGroupBuilder) Grants(ctx context.Context, resource *v2.Resource, pToken *pagination.Token) ([]*v2.Grant, string, annotations.Annotations, error) {
+	l := ctxzap.Extract(ctx)
+	page := 1
+	var err error
+	if pToken.Token != "" {
+		page, err = strconv.Atoi(pToken.Token)
+		if err != nil {
+			return nil, "", nil, fmt.Errorf("baton-procore: failed to parse page token: %w", err)
+		}
+	}
+
+	companyId, projectId, groupId, err := distributionGroupIds(resource)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	var annotations annotations.Annotations
+	users, res, rateLimitDesc, err := o.client.GetDistributionGroupUsers(ctx, companyId, projectId, groupId, page)
+	if err != nil {
+		if skipped, ok := skipUnavailableProject(ctx, projectId, "distribution group users", err); ok {
+			return nil, "", skipped, nil
+		}
+		return nil, "", nil, fmt.Errorf("baton-procore: error getting distribution group users: %w", err)
+	}
+	annotations = *annotations.WithRateLimiting(rateLimitDesc)
+
+	rv := make([]*v2.Grant, 0, len(users))
+	for _, user := range users {
+		userId, ok, err := o.companyUsers.resolve(ctx, companyId, user)
+		if err != nil {
+			return nil, "", nil, err
+		}
+		if !ok {
+			l.Debug("baton-procore: distribution group user has no email matching a company user, skipping",
+				zap.Int("distribution_group_id", groupId),
+				zap.Int("project_user_id", user.Id),
+			)
+			continue
+		}
+		principalID, err := resourceSdk.NewResourceID(userResourceType, userId)
+		if err != nil {
+			return nil, "", nil, fmt.Errorf("baton-procore: failed to create user resource ID: %w", err)
+		}
+		rv = append(rv, grant.NewGrant(
+			resource,
+			distributionGroupMembership,
+			principalID,
+		))
+	}
+
+	var nextPage string
+	if client.HasNextPage(res) {
+		nextPage = strconv.Itoa(page + 1)
+	}
+	return rv, nextPage, annotations, nil
+}
+
+func (o *distributionGroupBuilder) Grant(ctx context.Context, principal *v2.Resource, entitlement *v2.Entitlement) (annotations.Annotations, error) {
+	companyId, projectId, groupId, err := distributionGroupIds(entitlement.Resource)
+	if err != nil {
+		return nil, err
+	}
+	userId, err := strconv.Atoi(principal.Id.Resource)
+	if err != nil {
+		return nil, fmt.Errorf("baton-procore: failed to parse user id from grant principal: %w", err)
+	}
+
+	projectUser, err := o.companyUsers.projectUser(ctx, companyId, projectId, userId)
+	if err != nil {
+		return nil, err
+	}
+	if projectUser == nil {
+		return nil, fmt.Errorf("baton-procore: user %d must be added to project %s before joining its distribution groups", userId, projectId)
+	}
+
+	err = o.client.AddUserToDistributionGroup(ctx, companyId, projectId, groupId, projectUser.Id)
+	if err != nil {
+		return nil, fmt.Errorf("baton-procore: error adding user to distribution group: %w", err)
+	}
+
+	return nil, nil
+}
+
+func (o *distributionGroupBuilder) Revoke(ctx context.Context, grant *v2.Grant) (annotations.Annotations, error) {
+	companyId, projectId, groupId, err := distributionGroupIds(grant.Entitlement.Resource)
+	if err != nil {
+		return nil, err
+	}
+	userId, err := strconv.Atoi(grant.Principal.Id.Resource)
+	if err != nil {
+		return nil, fmt.Errorf("baton-procore: failed to parse user id from grant principal: %w", err)
+	}
+
+	projectUser, err := o.companyUsers.projectUser(ctx, companyId, projectId, userId)
+	if err != nil {
+		return nil, err
+	}
+	if projectUser == nil {
+		// leaving the project also removes the user from its distribution groups
+		return annotations.New(&v2.GrantAlreadyRevoked{}), nil
+	}
+
+	err = o.client.RemoveUserFromDistributionGroup(ctx, companyId, projectId, groupId, projectUser.Id)
+	if err != nil {
+		return nil, fmt.Errorf("baton-procore: error removing user from distribution group: %w", err)
+	}
+
+	return nil, nil
+}
+
+func distributionGroupIds(resource *v2.Resource) (string, string, int, error) {
+	companyId, err := getCompanyId(resource)
+	if err != nil {
+		return "", "", 0, fmt.Errorf("baton-procore: error getting company id from distribution group resource: %w", err)
+	}
+	projectId, err := getProjectId(resource)
+	if err != nil {
+		return "", "", 0, fmt.Errorf("baton-procore: error getting project id from distribution group resource: %w", err)
+	}
+	groupId, err := strconv.Atoi(resource.Id.Resource)
+	if err != nil {
+		return "", "", 0, fmt.Errorf("baton-procore: failed to parse distribution group id: %w", err)
+	}
+	return companyId, projectId, groupId, nil
+}
+
+func newDistributionGroupBuilder(client *client.Client, projectCompanies *projectCompanies, companyUsers *companyUsers) *distributionGroupBuilder {
+	return &distributionGroupBuilder{
+		client:           client,
+		projectCompanies: projectCompanies,
+		companyUsers:     companyUsers,
+	}
+}
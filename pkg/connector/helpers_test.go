@@ -0,0 +1,37 @@
+package connector
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/conductorone/baton-procore/pkg/client"
+)
+
+// newTestClient returns a client talking to a fake Procore that serves the routes registered on mux.
+// The base URL is the server root, so routes are registered without the /rest prefix.
+func newTestClient(t *testing.T, mux *http.ServeMux) *client.Client {
+	t.Helper()
+	mux.HandleFunc("POST /oauth/token", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]any{"access_token": "token", "token_type": "bearer", "expires_in": 3600})
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	c, err := client.New(context.Background(), "client-id", "client-secret",
+		client.WithBaseURL(srv.URL),
+		client.WithTokenURL(srv.URL+"/oauth/token"),
+		client.WithMaxAttempts(1),
+	)
+	if err != nil {
+		t.Fatalf("creating client: %v", err)
+	}
+	return c
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,75 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+
+	"github.com/conductorone/baton-procore/pkg/client"
+)
+
+// projectCompanies maps project ids to the id of the company that owns them. Children of
+// projects are listed with only the project id, while every project scoped Procore call
+// needs the Procore-Company-Id header.
+type projectCompanies struct {
+	client        *client.Client
+	companyFilter *companyFilter
+	mu            sync.RWMutex
+	m             map[string]string
+}
+
+func newProjectCompanies(client *client.Client, companyFilter *companyFilter) *projectCompanies {
+	return &projectCompanies{
+		client:        client,
+		companyFilter: companyFilter,
+		m:             make(map[string]string),
+	}
+}
+
+func (p *projectCompanies) set(projectId, companyId string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.m[projectId] = companyId
+}
+
+// get returns the company of the project, walking the companies and their projects
+// when the project has not been listed by this process yet.
+func (p *projectCompanies) get(ctx context.Context, projectId string) (string, error) {
+	p.mu.RLock()
+	companyId, ok := p.m[projectId]
+	p.mu.RUnlock()
+	if ok {
+		return companyId, nil
+	}
+
+	companies, err := listAllCompanies(ctx, p.client, p.companyFilter)
+	if err != nil {
+		return "", err
+	}
+	for _, company := range companies {
+		companyId := strconv.FormatInt(company.Id, 10)
+		projectPage := 1
+		for {
+			projects, res, _, err := p.client.GetProjects(ctx, companyId, projectPage)
+			if err != nil {
+				return "", fmt.Errorf("baton-procore: error getting projects: %w", err)
+			}
+			for _, project := range projects {
+				p.set(strconv.Itoa(project.Id), companyId)
+			}
+			if !client.HasNextPage(res) {
+				break
+			}
+			projectPage++
+		}
+	}
+
+	p.mu.RLock()
+	companyId, ok = p.m[projectId]
+	p.mu.RUnlock()
+	if !ok {
+		return "", fmt.Errorf("baton-procore: company not found for project %s", projectId)
+	}
+	return companyId, nil
+}
@@ -0,0 +1,79 @@
+package connector
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/conductorone/baton-procore/pkg/client"
+)
+
+// ProjectFilter limits the synced projects. Stages and types match either their ID or their name,
+// regions match their ID. Exclusions take precedence over inclusions.
+type ProjectFilter struct {
+	ActiveOnly       bool
+	SkipDemo         bool
+	IncludeStages    []string
+	ExcludeStages    []string
+	IncludeTypes     []string
+	ExcludeTypes     []string
+	IncludeRegionIds []string
+	ExcludeRegionIds []string
+}
+
+// serverFilters returns the part of the filter the Procore projects API applies itself.
+func (f ProjectFilter) serverFilters() client.ProjectFilters {
+	return client.ProjectFilters{
+		ActiveOnly: f.ActiveOnly,
+	}
+}
+
+// allows applies the whole filter to a listed project.
+func (f ProjectFilter) allows(project client.Project) bool {
+	if f.ActiveOnly && !project.Active {
+		return false
+	}
+	if f.SkipDemo && project.IsDemo {
+		return false
+	}
+
+	var stage []string
+	if project.ProjectStage != nil {
+		stage = []string{strconv.FormatInt(project.ProjectStage.Id, 10), project.ProjectStage.Name}
+	}
+	if !matchesFilter(f.IncludeStages, f.ExcludeStages, stage) {
+		return false
+	}
+
+	var projectType []string
+	if project.ProjectType != nil {
+		projectType = []string{strconv.Itoa(project.ProjectType.Id), project.ProjectType.Name}
+	}
+	if !matchesFilter(f.IncludeTypes, f.ExcludeTypes, projectType) {
+		return false
+	}
+
+	var region []string
+	if project.ProjectRegionId != nil {
+		region = []string{strconv.Itoa(*project.ProjectRegionId)}
+	}
+	return matchesFilter(f.IncludeRegionIds, f.ExcludeRegionIds, region)
+}
+
+// matchesFilter reports whether none of the values is excluded and, when there is an include list,
+// one of them is included. Values are compared case insensitively.
+func matchesFilter(include, exclude, values []string) bool {
+	contains := func(list []string) bool {
+		for _, item := range list {
+			for _, value := range values {
+				if value != "" && strings.EqualFold(strings.TrimSpace(item), value) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	if contains(exclude) {
+		return false
+	}
+	return len(include) == 0 || contains(include)
+}
@@ -0,0 +1,266 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/conductorone/baton-procore/pkg/client"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	"github.com/conductorone/baton-sdk/pkg/types/entitlement"
+	"github.com/conductorone/baton-sdk/pkg/types/grant"
+	resourceSdk "github.com/conductorone/baton-sdk/pkg/types/resource"
+	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
+	"go.uber.org/zap"
+)
+
+const projectRoleAssignment = "assigned"
+
+// projectRoleBuilder syncs the role slots of each project. Every slot holds at most one user.
+type projectRoleBuilder struct {
+	client           *client.Client
+	projectCompanies *projectCompanies
+	companyUsers     *companyUsers
+}
+
+func (o *projectRoleBuilder) ResourceType(ctx context.Context) *v2.ResourceType {
+	return projectRoleResourceType
+}
+
+func projectRoleResource(companyId string, role client.ProjectRole, parentResourceID *v2.ResourceId) (*v2.Resource, error) {
+	profile := map[string]any{
+		"company_id": companyId,
+		"project_id": parentResourceID.Resource,
+		"role":       role.Role,
+	}
+	if role.UserId != nil {
+		profile["user_id"] = strconv.Itoa(*role.UserId)
+	}
+	return resourceSdk.NewRoleResource(
+		role.Role,
+		projectRoleResourceType,
+		role.Id,
+		[]resourceSdk.RoleTraitOption{
+			resourceSdk.WithRoleProfile(profile),
+		},
+		resourceSdk.WithParentResourceID(parentResourceID),
+	)
+}
+
+func (o *projectRoleBuilder) List(ctx context.Context, parentResourceID *v2.ResourceId, pToken *pagination.Token) ([]*v2.Resource, string, annotations.Annotations, error) {
+	if parentResourceID == nil {
+		return nil, "", nil, nil
+	}
+
+	page := 1
+	var err error
+	if pToken.Token != "" {
+		page, err = strconv.Atoi(pToken.Token)
+		if err != nil {
+			return nil, "", nil, fmt.Errorf("baton-procore: failed to parse page token: %w", err)
+		}
+	}
+
+	companyId, err := o.projectCompanies.get(ctx, parentResourceID.Resource)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	var annotations annotations.Annotations
+	roles, res, rateLimitDesc, err := o.client.GetProjectRoles(ctx, companyId, parentResourceID.Resource, page)
+	if err != nil {
+		if skipped, ok := skipUnavailableProject(ctx, parentResourceID.Resource, "project roles", err); ok {
+			return nil, "", skipped, nil
+		}
+		return nil, "", nil, fmt.Errorf("baton-procore: error getting project roles: %w", err)
+	}
+	annotations = *annotations.WithRateLimiting(rateLimitDesc)
+
+	rv := make([]*v2.Resource, 0, len(roles))
+	for _, role := range roles {
+		resource, err := projectRoleResource(companyId, role, parentResourceID)
+		if err != nil {
+			return nil, "", nil, fmt.Errorf("baton-procore: error converting project role to resource: %w", err)
+		}
+		rv = append(rv, resource)
+	}
+
+	var nextPage string
+	if client.HasNextPage(res) {
+		nextPage = strconv.Itoa(page + 1)
+	}
+	return rv, nextPage, annotations, nil
+}
+
+func (o *projectRoleBuilder) Entitlements(_ context.Context, resource *v2.Resource, _ *pagination.Token) ([]*v2.Entitlement, string, annotations.Annotations, error) {
+	return []*v2.Entitlement{
+		entitlement.NewAssignmentEntitlement(
+			resource,
+			projectRoleAssignment,
+			entitlement.WithGrantableTo(userResourceType),
+			entitlement.WithDescription(fmt.Sprintf("Assigned the %s project role", resource.DisplayName)),
+			entitlement.WithDisplayName(fmt.Sprintf("%s project role", resource.DisplayName)),
+		),
+	}, "", nil, nil
+}
+
+// Grants reads the assigned project user from the resource profile, which is filled when the role is
+// listed, and maps it to the company user resource.
+func (o *projectRoleBuilder) Grants(ctx context.Context, resource *v2.Resource, pToken *pagination.Token) ([]*v2.Grant, string, annotations.Annotations, error) {
+	l := ctxzap.Extract(ctx)
+	companyId, projectId, roleId, err := projectRoleIds(resource)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	roleTrait, err := resourceSdk.GetRoleTrait(resource)
+	if err != nil {
+		return nil, "", nil, fmt.Errorf("baton-procore: error getting role traits: %w", err)
+	}
+	rawProjectUserId, ok := resourceSdk.GetProfileStringValue(roleTrait.GetProfile(), "user_id")
+	if !ok {
+		return nil, "", nil, nil
+	}
+	projectUserId, err := strconv.Atoi(rawProjectUserId)
+	if err != nil {
+		return nil, "", nil, fmt.Errorf("baton-procore: failed to parse project role user id: %w", err)
+	}
+
+	userId, ok, err := o.companyUsers.resolveProjectUserId(ctx, companyId, projectId, projectUserId)
+	if err != nil {
+		if skipped, ok := skipUnavailableProject(ctx, projectId, "project users", err); ok {
+			return nil, "", skipped, nil
+		}
+		return nil, "", nil, err
+	}
+	if !ok {
+		l.Debug("baton-procore: project role user has no email matching a company user, skipping",
+			zap.Int("project_role_id", roleId),
+			zap.Int("project_user_id", projectUserId),
+		)
+		return nil, "", nil, nil
+	}
+
+	principalID, err := resourceSdk.NewResourceID(userResourceType, userId)
+	if err != nil {
+		return nil, "", nil, fmt.Errorf("baton-procore: failed to create user resource ID: %w", err)
+	}
+	return []*v2.Grant{
+		grant.NewGrant(
+			resource,
+			projectRoleAssignment,
+			principalID,
+		),
+	}, "", nil, nil
+}
+
+func (o *projectRoleBuilder) Grant(ctx context.Context, principal *v2.Resource, entitlement *v2.Entitlement) (annotations.Annotations, error) {
+	companyId, projectId, roleId, err := projectRoleIds(entitlement.Resource)
+	if err != nil {
+		return nil, err
+	}
+	userId, err := strconv.Atoi(principal.Id.Resource)
+	if err != nil {
+		return nil, fmt.Errorf("baton-procore: failed to parse user id from grant principal: %w", err)
+	}
+
+	projectUser, err := o.companyUsers.projectUser(ctx, companyId, projectId, userId)
+	if err != nil {
+		return nil, err
+	}
+	if projectUser == nil {
+		return nil, fmt.Errorf("baton-procore: user %d must be added to project %s before being assigned a project role", userId, projectId)
+	}
+
+	err = o.client.SetProjectRoleUser(ctx, companyId, projectId, roleId, &projectUser.Id)
+	if err != nil {
+		return nil, fmt.Errorf("baton-procore: error assigning project role: %w", err)
+	}
+
+	return nil, nil
+}
+
+// Revoke clears the role, unless it has been handed to another user in the meantime.
+func (o *projectRoleBuilder) Revoke(ctx context.Context, grant *v2.Grant) (annotations.Annotations, error) {
+	companyId, projectId, roleId, err := projectRoleIds(grant.Entitlement.Resource)
+	if err != nil {
+		return nil, err
+	}
+	userId, err := strconv.Atoi(grant.Principal.Id.Resource)
+	if err != nil {
+		return nil, fmt.Errorf("baton-procore: failed to parse user id from grant principal: %w", err)
+	}
+
+	projectUser, err := o.companyUsers.projectUser(ctx, companyId, projectId, userId)
+	if err != nil {
+		return nil, err
+	}
+	if projectUser == nil {
+		return annotations.New(&v2.GrantAlreadyRevoked{}), nil
+	}
+
+	role, err := o.findProjectRole(ctx, companyId, projectId, roleId)
+	if err != nil {
+		return nil, err
+	}
+	if role == nil || role.UserId == nil || *role.UserId != projectUser.Id {
+		return annotations.New(&v2.GrantAlreadyRevoked{}), nil
+	}
+
+	err = o.client.SetProjectRoleUser(ctx, companyId, projectId, roleId, nil)
+	if err != nil {
+		return nil, fmt.Errorf("baton-procore: error clearing project role: %w", err)
+	}
+
+	return nil, nil
+}
+
+// findProjectRole returns nil when the role no longer exists on the project. The roles are read past
+// the HTTP cache, as the sync already cached them before the role was assigned.
+func (o *projectRoleBuilder) findProjectRole(ctx context.Context, companyId, projectId string, roleId int) (*client.ProjectRole, error) {
+	page := 1
+	for {
+		roles, res, _, err := o.client.Uncached().GetProjectRoles(ctx, companyId, projectId, page)
+		if err != nil {
+			return nil, fmt.Errorf("baton-procore: error getting project roles: %w", err)
+		}
+		for _, role := range roles {
+			if role.Id == roleId {
+				return &role, nil
+			}
+		}
+		if !client.HasNextPage(res) {
+			return nil, nil
+		}
+		page++
+	}
+}
+
+func projectRoleIds(resource *v2.Resource) (string, string, int, error) {
+	roleTrait, err := resourceSdk.GetRoleTrait(resource)
+	if err != nil {
+		return "", "", 0, fmt.Errorf("baton-procore: error getting role traits: %w", err)
+	}
+	companyId, ok := resourceSdk.GetProfileStringValue(roleTrait.GetProfile(), "company_id")
+	if !ok {
+		return "", "", 0, fmt.Errorf("baton-procore: company_id not found in %s resource profile", resource.Id.ResourceType)
+	}
+	projectId, ok := resourceSdk.GetProfileStringValue(roleTrait.GetProfile(), "project_id")
+	if !ok {
+		return "", "", 0, fmt.Errorf("baton-procore: project_id not found in %s resource profile", resource.Id.ResourceType)
+	}
+	roleId, err := strconv.Atoi(resource.Id.Resource)
+	if err != nil {
+		return "", "", 0, fmt.Errorf("baton-procore: failed to parse project role id: %w", err)
+	}
+	return companyId, projectId, roleId, nil
+}
+
+func newProjectRoleBuilder(client *client.Client, projectCompanies *projectCompanies, companyUsers *companyUsers) *projectRoleBuilder {
+	return &projectRoleBuilder{
+		client:           client,
+		projectCompanies: projectCompanies,
+		companyUsers:     companyUsers,
+	}
+}
@@ -0,0 +1,95 @@
+package connector
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/conductorone/baton-procore/pkg/client"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	"github.com/conductorone/baton-sdk/pkg/types/grant"
+	resourceSdk "github.com/conductorone/baton-sdk/pkg/types/resource"
+)
+
+func TestProjectRoleMapsProjectUserIds(t *testing.T) {
+	ctx := context.Background()
+	const (
+		roleId        = 3
+		projectUserId = 70
+	)
+
+	var mu sync.Mutex
+	var assigned *int
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1.3/companies/1/users", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, []client.User{{Id: 7, EmailAddress: "ada@example.com"}})
+	})
+	mux.HandleFunc("GET /v1.3/companies/1/users/7", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, client.User{Id: 7, EmailAddress: "ada@example.com"})
+	})
+	mux.HandleFunc("GET /v1.0/projects/100/users", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, []client.User{{Id: projectUserId, EmailAddress: "ada@example.com"}})
+	})
+	mux.HandleFunc("GET /v1.0/project_roles", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		writeJSON(w, []client.ProjectRole{{Id: roleId, Role: "Project Manager", UserId: assigned}})
+	})
+	mux.HandleFunc("PATCH /v1.0/project_roles/3", func(w http.ResponseWriter, r *http.Request) {
+		var body client.UpdateProjectRoleBody
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		assigned = body.ProjectRole.UserId
+		writeJSON(w, client.ProjectRole{Id: roleId, UserId: assigned})
+	})
+	c := newTestClient(t, mux)
+	builder := newProjectRoleBuilder(c, nil, newCompanyUsers(c, newCompanyFilter(nil, nil)))
+
+	parent := &v2.ResourceId{ResourceType: projectResourceType.Id, Resource: "100"}
+	principal, err := resourceSdk.NewResourceID(userResourceType, 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	role, err := projectRoleResource("1", client.ProjectRole{Id: roleId, Role: "Project Manager"}, parent)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = builder.Grant(ctx, &v2.Resource{Id: principal}, &v2.Entitlement{Resource: role})
+	if err != nil {
+		t.Fatalf("grant: %v", err)
+	}
+	if assigned == nil || *assigned != projectUserId {
+		t.Fatalf("the role was assigned to %v, want the project user %d", assigned, projectUserId)
+	}
+
+	role, err = projectRoleResource("1", client.ProjectRole{Id: roleId, Role: "Project Manager", UserId: assigned}, parent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	grants, _, _, err := builder.Grants(ctx, role, &pagination.Token{})
+	if err != nil {
+		t.Fatalf("grants: %v", err)
+	}
+	if len(grants) != 1 || grants[0].Principal.Id.Resource != "7" {
+		t.Fatalf("grants %v, want one grant to the company user 7", grants)
+	}
+
+	annos, err := builder.Revoke(ctx, grant.NewGrant(role, projectRoleAssignment, principal))
+	if err != nil {
+		t.Fatalf("revoke: %v", err)
+	}
+	if annos.Contains(&v2.GrantAlreadyRevoked{}) {
+		t.Fatal("revoke did not match the project user holding the role")
+	}
+	if assigned != nil {
+		t.Fatalf("the role is still assigned to %d after revoke", *assigned)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"strconv"
+	"strings"
 
 	"github.com/conductorone/baton-procore/pkg/client"
 	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
@@ -12,12 +13,27 @@ import (
 	"github.com/conductorone/baton-sdk/pkg/types/entitlement"
 	"github.com/conductorone/baton-sdk/pkg/types/grant"
 	resourceSdk "github.com/conductorone/baton-sdk/pkg/types/resource"
+	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
 )
 
-const projectMembership = "member"
+const (
+	projectMembership = "member"
+	projectVendor     = "vendor"
+	// projectVendorsPageToken prefixes the Grants page tokens once the project users are exhausted.
+	projectVendorsPageToken = "vendors:"
+	// projectTemplatePrefix prefixes the per project permission template entitlements, e.g. "template:1234".
+	projectTemplatePrefix = "template:"
+)
 
 type projectBuilder struct {
-	client *client.Client
+	client           *client.Client
+	projectCompanies *projectCompanies
+	companyUsers     *companyUsers
+	projectFilter    ProjectFilter
 }
 
 func getCompanyId(resource *v2.Resource) (string, error) {
@@ -28,11 +44,29 @@ func getCompanyId(resource *v2.Resource) (string, error) {
 	traits := groupTrait.GetProfile().AsMap()
 	companyId, ok := traits["company_id"].(string)
 	if !ok {
-		return "", fmt.Errorf("baton-procore: company_id not found in project resource profile")
+		return "", fmt.Errorf("baton-procore: company_id not found in %s resource profile", resource.Id.ResourceType)
 	}
 	return companyId, nil
 }
 
+func projectTemplateEntitlementName(templateId int) string {
+	return fmt.Sprintf("%s%d", projectTemplatePrefix, templateId)
+}
+
+// parseProjectTemplateEntitlement returns the permission template ID of a per template
+// entitlement, and false for any other project entitlement.
+func parseProjectTemplateEntitlement(entitlement *v2.Entitlement) (int, bool, error) {
+	slug := strings.TrimPrefix(entitlement.Id, fmt.Sprintf("%s:%s:", projectResourceType.Id, entitlement.Resource.Id.Resource))
+	if !strings.HasPrefix(slug, projectTemplatePrefix) {
+		return 0, false, nil
+	}
+	templateId, err := strconv.Atoi(strings.TrimPrefix(slug, projectTemplatePrefix))
+	if err != nil {
+		return 0, false, fmt.Errorf("baton-procore: failed to parse project permission template id from entitlement %s: %w", entitlement.Id, err)
+	}
+	return templateId, true, nil
+}
+
 func (o *projectBuilder) ResourceType(ctx context.Context) *v2.ResourceType {
 	return projectResourceType
 }
@@ -50,6 +84,10 @@ func projectResource(project client.Project) (*v2.Resource, error) {
 		[]resourceSdk.GroupTraitOption{
 			resourceSdk.WithGroupProfile(profile),
 		},
+		resourceSdk.WithAnnotation(
+			&v2.ChildResourceType{ResourceTypeId: distributionGroupResourceType.Id},
+			&v2.ChildResourceType{ResourceTypeId: projectRoleResourceType.Id},
+		),
 	)
 }
 
@@ -68,7 +106,7 @@ func (o *projectBuilder) List(ctx context.Context, parentResourceID *v2.Resource
 	}
 
 	var annotations annotations.Annotations
-	projects, res, rateLimitDesc, err := o.client.GetProjects(ctx, parentResourceID.Resource, page)
+	projects, res, rateLimitDesc, err := o.client.GetFilteredProjects(ctx, parentResourceID.Resource, page, o.projectFilter.serverFilters())
 	if err != nil {
 		return nil, "", nil, fmt.Errorf("baton-procore: error getting projects: %w", err)
 	}
@@ -76,6 +114,10 @@ func (o *projectBuilder) List(ctx context.Context, parentResourceID *v2.Resource
 
 	rv := make([]*v2.Resource, 0, len(projects))
 	for _, project := range projects {
+		if !o.projectFilter.allows(project) {
+			continue
+		}
+		o.projectCompanies.set(strconv.Itoa(project.Id), parentResourceID.Resource)
 		resource, err := projectResource(project)
 		if err != nil {
 			return nil, "", nil, fmt.Errorf("baton-procore: error converting project to resource: %w", err)
@@ -91,19 +133,9 @@ func (o *projectBuilder) List(ctx context.Context, parentResourceID *v2.Resource
 	return rv, nextPage, annotations, nil
 }
 
-func (o *projectBuilder) Entitlements(_ context.Context, resource *v2.Resource, _ *pagination.Token) ([]*v2.Entitlement, string, annotations.Annotations, error) {
-	return []*v2.Entitlement{
-		entitlement.NewAssignmentEntitlement(
-			resource,
-			projectMembership,
-			entitlement.WithGrantableTo(userResourceType),
-			entitlement.WithDescription(fmt.Sprintf("Member of %s project", resource.DisplayName)),
-			entitlement.WithDisplayName(fmt.Sprintf("Member of %s project", resource.DisplayName)),
-		),
-	}, "", nil, nil
-}
-
-func (o *projectBuilder) Grants(ctx context.Context, resource *v2.Resource, pToken *pagination.Token) ([]*v2.Grant, string, annotations.Annotations, error) {
+// Entitlements returns the project membership entitlement, followed by one entitlement per
+// project permission template.
+func (o *projectBuilder) Entitlements(ctx context.Context, resource *v2.Resource, pToken *pagination.Token) ([]*v2.Entitlement, string, annotations.Annotations, error) {
 	page := 1
 	var err error
 	if pToken.Token != "" {
@@ -113,57 +145,248 @@ func (o *projectBuilder) Grants(ctx context.Context, resource *v2.Resource, pTok
 		}
 	}
 
+	companyId, err := getCompanyId(resource)
+	if err != nil {
+		return nil, "", nil, fmt.Errorf("baton-procore: error getting company id from project resource: %w", err)
+	}
+
+	var annotations annotations.Annotations
+	templates, res, rateLimitDesc, err := o.client.GetProjectPermissionTemplates(ctx, companyId, resource.Id.Resource, page)
+	if err != nil {
+		skipped, ok := skipUnavailableProject(ctx, resource.Id.Resource, "permission templates", err)
+		if !ok {
+			return nil, "", nil, fmt.Errorf("baton-procore: error getting project permission templates: %w", err)
+		}
+		// the member and vendor entitlements are still emitted, only the template ones are skipped
+		annotations = skipped
+	} else {
+		annotations = *annotations.WithRateLimiting(rateLimitDesc)
+	}
+
+	rv := make([]*v2.Entitlement, 0, len(templates)+2)
+	if page == 1 {
+		rv = append(rv,
+			entitlement.NewAssignmentEntitlement(
+				resource,
+				projectMembership,
+				entitlement.WithGrantableTo(userResourceType),
+				entitlement.WithDescription(fmt.Sprintf("Member of %s project", resource.DisplayName)),
+				entitlement.WithDisplayName(fmt.Sprintf("Member of %s project", resource.DisplayName)),
+			),
+			entitlement.NewAssignmentEntitlement(
+				resource,
+				projectVendor,
+				entitlement.WithGrantableTo(vendorResourceType),
+				entitlement.WithDescription(fmt.Sprintf("Vendor on %s project", resource.DisplayName)),
+				entitlement.WithDisplayName(fmt.Sprintf("Vendor on %s project", resource.DisplayName)),
+			),
+		)
+	}
+	for _, template := range templates {
+		rv = append(rv, entitlement.NewAssignmentEntitlement(
+			resource,
+			projectTemplateEntitlementName(template.Id),
+			entitlement.WithGrantableTo(userResourceType),
+			entitlement.WithDescription(fmt.Sprintf("%s on %s project", template.Name, resource.DisplayName)),
+			entitlement.WithDisplayName(fmt.Sprintf("%s on %s project", template.Name, resource.DisplayName)),
+		))
+	}
+
+	var nextPage string
+	if client.HasNextPage(res) {
+		nextPage = strconv.Itoa(page + 1)
+	}
+	return rv, nextPage, annotations, nil
+}
+
+// Grants pages through the project users first, then through the project vendors. Vendor pages
+// use a "vendors:" prefixed page token.
+func (o *projectBuilder) Grants(ctx context.Context, resource *v2.Resource, pToken *pagination.Token) ([]*v2.Grant, string, annotations.Annotations, error) {
 	// get company id from resource groupTrait
 	companyId, err := getCompanyId(resource)
 	if err != nil {
 		return nil, "", nil, fmt.Errorf("baton-procore: error getting company id from project resource: %w", err)
 	}
 
+	if strings.HasPrefix(pToken.Token, projectVendorsPageToken) {
+		page, err := strconv.Atoi(strings.TrimPrefix(pToken.Token, projectVendorsPageToken))
+		if err != nil {
+			return nil, "", nil, fmt.Errorf("baton-procore: failed to parse page token: %w", err)
+		}
+		return o.vendorGrants(ctx, resource, companyId, page)
+	}
+
+	page := 1
+	if pToken.Token != "" {
+		page, err = strconv.Atoi(pToken.Token)
+		if err != nil {
+			return nil, "", nil, fmt.Errorf("baton-procore: failed to parse page token: %w", err)
+		}
+	}
+	return o.userGrants(ctx, resource, companyId, page)
+}
+
+// userGrants attaches the project users to their company user resources.
+func (o *projectBuilder) userGrants(ctx context.Context, resource *v2.Resource, companyId string, page int) ([]*v2.Grant, string, annotations.Annotations, error) {
+	l := ctxzap.Extract(ctx)
 	var annotations annotations.Annotations
 	users, res, rateLimitDesc, err := o.client.GetProjectUsers(ctx, companyId, resource.Id.Resource, page)
 	if err != nil {
+		if skipped, ok := skipUnavailableProject(ctx, resource.Id.Resource, "users", err); ok {
+			return nil, projectVendorsPageToken + "1", skipped, nil
+		}
 		return nil, "", nil, fmt.Errorf("baton-procore: error getting users: %w", err)
 	}
 	annotations = *annotations.WithRateLimiting(rateLimitDesc)
 
 	rv := make([]*v2.Grant, 0, len(users))
 	for _, user := range users {
-		principalID, err := resourceSdk.NewResourceID(userResourceType, user.Id)
+		userId, ok, err := o.companyUsers.resolve(ctx, companyId, user)
+		if err != nil {
+			return nil, "", nil, err
+		}
+		if !ok {
+			l.Debug("baton-procore: project user has no email matching a company user, skipping",
+				zap.String("project_id", resource.Id.Resource),
+				zap.Int("project_user_id", user.Id),
+			)
+			continue
+		}
+		principalID, err := resourceSdk.NewResourceID(userResourceType, userId)
 		if err != nil {
 			return nil, "", nil, fmt.Errorf("baton-procore: failed to create user resource ID: %w", err)
 		}
 		rv = append(rv, grant.NewGrant(
 			resource,
-			companyMembership,
+			projectMembership,
 			principalID,
 		))
+		if user.PermissionTemplate.Id != 0 {
+			rv = append(rv, grant.NewGrant(
+				resource,
+				projectTemplateEntitlementName(user.PermissionTemplate.Id),
+				principalID,
+			))
+		}
 	}
-	var nextPage string
+
+	nextPage := projectVendorsPageToken + "1"
 	if client.HasNextPage(res) {
 		nextPage = strconv.Itoa(page + 1)
 	}
 	return rv, nextPage, annotations, nil
 }
 
+func (o *projectBuilder) vendorGrants(ctx context.Context, resource *v2.Resource, companyId string, page int) ([]*v2.Grant, string, annotations.Annotations, error) {
+	var annotations annotations.Annotations
+	vendors, res, rateLimitDesc, err := o.client.GetProjectVendors(ctx, companyId, resource.Id.Resource, page)
+	if err != nil {
+		if skipped, ok := skipUnavailableProject(ctx, resource.Id.Resource, "vendors", err); ok {
+			return nil, "", skipped, nil
+		}
+		return nil, "", nil, fmt.Errorf("baton-procore: error getting project vendors: %w", err)
+	}
+	annotations = *annotations.WithRateLimiting(rateLimitDesc)
+
+	rv := make([]*v2.Grant, 0, len(vendors))
+	for _, vendor := range vendors {
+		principalID, err := resourceSdk.NewResourceID(vendorResourceType, vendor.Id)
+		if err != nil {
+			return nil, "", nil, fmt.Errorf("baton-procore: failed to create vendor resource ID: %w", err)
+		}
+		rv = append(rv, grant.NewGrant(
+			resource,
+			projectVendor,
+			principalID,
+		))
+	}
+
+	var nextPage string
+	if client.HasNextPage(res) {
+		nextPage = projectVendorsPageToken + strconv.Itoa(page+1)
+	}
+	return rv, nextPage, annotations, nil
+}
+
+// Grant adds the user or vendor to the project. For permission template entitlements the template
+// is set in the same call, or updated when the user is already on the project.
 func (o *projectBuilder) Grant(ctx context.Context, principal *v2.Resource, entitlement *v2.Entitlement) (annotations.Annotations, error) {
 	projectId := entitlement.Resource.Id.Resource
 	companyId, err := getCompanyId(entitlement.Resource)
 	if err != nil {
 		return nil, fmt.Errorf("baton-procore: error getting company id from project resource: %w", err)
 	}
+
+	if principal.Id.ResourceType == vendorResourceType.Id {
+		vendorId, err := strconv.Atoi(principal.Id.Resource)
+		if err != nil {
+			return nil, fmt.Errorf("baton-procore: failed to parse vendor id from grant principal: %w", err)
+		}
+		err = o.client.AddVendorToProject(ctx, companyId, projectId, vendorId)
+		if err != nil {
+			return nil, fmt.Errorf("baton-procore: error adding vendor to project: %w", err)
+		}
+		return nil, nil
+	}
+
 	userId, err := strconv.Atoi(principal.Id.Resource)
 	if err != nil {
 		return nil, fmt.Errorf("baton-procore: failed to parse user id from grant principal: %w", err)
 	}
+	templateId, isTemplate, err := parseProjectTemplateEntitlement(entitlement)
+	if err != nil {
+		return nil, err
+	}
 
-	err = o.client.AddUserToProject(ctx, companyId, projectId, userId)
+	if !isTemplate {
+		err = o.client.AddUserToProject(ctx, companyId, projectId, userId, nil)
+		if err != nil {
+			return nil, fmt.Errorf("baton-procore: error adding user to project: %w", err)
+		}
+		return nil, nil
+	}
+
+	alreadySet, err := addUserToProjectWithTemplate(ctx, o.companyUsers, companyId, projectId, userId, templateId)
 	if err != nil {
-		return nil, fmt.Errorf("baton-procore: error adding user to project: %w", err)
+		return nil, err
+	}
+	if alreadySet {
+		return annotations.New(&v2.GrantAlreadyExists{}), nil
 	}
 
 	return nil, nil
 }
 
+// addUserToProjectWithTemplate adds the company user to the project with the permission template, or
+// updates the template of their project user when they are already on the project. It reports whether
+// the user already had the template.
+func addUserToProjectWithTemplate(ctx context.Context, users *companyUsers, companyId, projectId string, userId, templateId int) (bool, error) {
+	projectUser, err := users.projectUser(ctx, companyId, projectId, userId)
+	if err != nil {
+		return false, err
+	}
+
+	if projectUser == nil {
+		err = users.client.AddUserToProject(ctx, companyId, projectId, userId, &templateId)
+		if err != nil {
+			return false, fmt.Errorf("baton-procore: error adding user to project: %w", err)
+		}
+		return false, nil
+	}
+
+	if projectUser.PermissionTemplate.Id == templateId {
+		return true, nil
+	}
+
+	err = users.client.UpdateProjectUserPermissionTemplate(ctx, companyId, projectId, projectUser.Id, templateId)
+	if err != nil {
+		return false, fmt.Errorf("baton-procore: error updating project permission template: %w", err)
+	}
+	return false, nil
+}
+
+// Revoke removes the user or vendor from the project. A permission template grant is only revoked
+// while the user still holds that template, since a user has a single template per project.
 func (o *projectBuilder) Revoke(ctx context.Context, grant *v2.Grant) (annotations.Annotations, error) {
 	entitlement := grant.Entitlement
 	projectId := entitlement.Resource.Id.Resource
@@ -171,12 +394,37 @@ func (o *projectBuilder) Revoke(ctx context.Context, grant *v2.Grant) (annotatio
 	if err != nil {
 		return nil, fmt.Errorf("baton-procore: error getting company id from project resource: %w", err)
 	}
+
+	if grant.Principal.Id.ResourceType == vendorResourceType.Id {
+		vendorId, err := strconv.Atoi(grant.Principal.Id.Resource)
+		if err != nil {
+			return nil, fmt.Errorf("baton-procore: failed to parse vendor id from grant principal: %w", err)
+		}
+		err = o.client.RemoveVendorFromProject(ctx, companyId, projectId, vendorId)
+		if err != nil {
+			return nil, fmt.Errorf("baton-procore: error removing vendor from project: %w", err)
+		}
+		return nil, nil
+	}
+
 	userId, err := strconv.Atoi(grant.Principal.Id.Resource)
 	if err != nil {
 		return nil, fmt.Errorf("baton-procore: failed to parse user id from grant principal: %w", err)
 	}
+	templateId, isTemplate, err := parseProjectTemplateEntitlement(entitlement)
+	if err != nil {
+		return nil, err
+	}
 
-	err = o.client.RemoveUserFromProject(ctx, companyId, projectId, userId)
+	projectUser, err := o.companyUsers.projectUser(ctx, companyId, projectId, userId)
+	if err != nil {
+		return nil, err
+	}
+	if projectUser == nil || (isTemplate && projectUser.PermissionTemplate.Id != templateId) {
+		return annotations.New(&v2.GrantAlreadyRevoked{}), nil
+	}
+
+	err = o.client.RemoveUserFromProject(ctx, companyId, projectId, projectUser.Id)
 	if err != nil {
 		return nil, fmt.Errorf("baton-procore: error removing user from project: %w", err)
 	}
@@ -184,8 +432,35 @@ func (o *projectBuilder) Revoke(ctx context.Context, grant *v2.Grant) (annotatio
 	return nil, nil
 }
 
-func newProjectBuilder(client *client.Client) *projectBuilder {
+// skipUnavailableProject reports whether the request failed because the project, or the tool behind the
+// request, cannot be read: the project directory is disabled, the app lacks access or the project was
+// deleted mid-sync. Those errors are logged so a single project does not fail the whole sync, and the
+// returned annotation is attached to the response so access reviews can tell the project data is missing.
+func skipUnavailableProject(ctx context.Context, projectId, data string, err error) (annotations.Annotations, bool) {
+	switch status.Code(err) {
+	case codes.PermissionDenied, codes.NotFound:
+		ctxzap.Extract(ctx).Warn("baton-procore: skipping project data that cannot be read",
+			zap.String("project_id", projectId),
+			zap.String("data", data),
+			zap.Error(err),
+		)
+		return annotations.New(&structpb.Struct{
+			Fields: map[string]*structpb.Value{
+				"skipped_project_id":   structpb.NewStringValue(projectId),
+				"skipped_project_data": structpb.NewStringValue(data),
+				"reason":               structpb.NewStringValue(status.Convert(err).Message()),
+			},
+		}), true
+	default:
+		return nil, false
+	}
+}
+
+func newProjectBuilder(client *client.Client, projectCompanies *projectCompanies, companyUsers *companyUsers, projectFilter ProjectFilter) *projectBuilder {
 	return &projectBuilder{
-		client: client,
+		client:           client,
+		projectCompanies: projectCompanies,
+		companyUsers:     companyUsers,
+		projectFilter:    projectFilter,
 	}
 }
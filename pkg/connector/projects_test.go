@@ -0,0 +1,115 @@
+package connector
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/conductorone/baton-procore/pkg/client"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestAddUserToProjectWithTemplateAfterRemoval(t *testing.T) {
+	ctx := context.Background()
+	const (
+		templateId    = 5
+		projectUserId = 70
+	)
+
+	var mu sync.Mutex
+	onProject := true
+	added := 0
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1.3/companies/1/users/7", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, client.User{Id: 7, EmailAddress: "ada@example.com"})
+	})
+	mux.HandleFunc("GET /v1.0/projects/100/users", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if !onProject {
+			writeJSON(w, []client.User{})
+			return
+		}
+		writeJSON(w, []client.User{{Id: projectUserId, EmailAddress: "ada@example.com", PermissionTemplate: client.PermissionTemplate{Id: templateId}}})
+	})
+	mux.HandleFunc("DELETE /v1.0/projects/100/users/70/actions/remove", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		onProject = false
+		w.WriteHeader(http.StatusNoContent)
+	})
+	// adding takes the company user id, every other project endpoint the project user id
+	mux.HandleFunc("POST /v1.0/projects/100/users/7/actions/add", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		onProject = true
+		added++
+		writeJSON(w, client.User{Id: projectUserId})
+	})
+	c := newTestClient(t, mux)
+	users := newCompanyUsers(c, newCompanyFilter(nil, nil))
+
+	// the membership is cached by the sync, then the user is removed from the project
+	if _, err := c.FindProjectUserByEmail(ctx, "1", "100", "ada@example.com"); err != nil {
+		t.Fatalf("reading project users: %v", err)
+	}
+	if err := c.RemoveUserFromProject(ctx, "1", "100", projectUserId); err != nil {
+		t.Fatalf("removing project user: %v", err)
+	}
+
+	alreadySet, err := addUserToProjectWithTemplate(ctx, users, "1", "100", 7, templateId)
+	if err != nil {
+		t.Fatalf("adding project user: %v", err)
+	}
+	if alreadySet {
+		t.Fatal("the removed user was reported as still on the project")
+	}
+	if added != 1 {
+		t.Fatalf("the user was added %d times, want 1", added)
+	}
+
+	alreadySet, err = addUserToProjectWithTemplate(ctx, users, "1", "100", 7, templateId)
+	if err != nil {
+		t.Fatalf("adding project user again: %v", err)
+	}
+	if !alreadySet {
+		t.Fatal("the project user id was not matched to the company user")
+	}
+}
+
+func TestProjectGrantsAnnotateUnavailableProject(t *testing.T) {
+	ctx := context.Background()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1.0/projects/100/users", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"errors":"Project Directory is disabled"}`, http.StatusForbidden)
+	})
+	c := newTestClient(t, mux)
+	builder := newProjectBuilder(c, nil, newCompanyUsers(c, newCompanyFilter(nil, nil)), ProjectFilter{})
+
+	resource, err := projectResource(client.Project{Id: 100, Name: "Tower", Company: client.Company{Id: 1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	grants, nextPage, annos, err := builder.Grants(ctx, resource, &pagination.Token{})
+	if err != nil {
+		t.Fatalf("grants: %v", err)
+	}
+	if len(grants) != 0 || nextPage != projectVendorsPageToken+"1" {
+		t.Fatalf("got %d grants and page %q, want the vendors page next", len(grants), nextPage)
+	}
+
+	skipped := &structpb.Struct{}
+	ok, err := annos.Pick(skipped)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ok {
+		t.Fatal("the skipped project users were not annotated on the response")
+	}
+	if skipped.GetFields()["skipped_project_id"].GetStringValue() != "100" {
+		t.Fatalf("annotation %v does not name project 100", skipped)
+	}
+}
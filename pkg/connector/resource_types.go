@@ -22,3 +22,27 @@ var projectResourceType = &v2.ResourceType{
 	DisplayName: "Project",
 	Traits:      []v2.ResourceType_Trait{v2.ResourceType_TRAIT_GROUP},
 }
+
+var companyPermissionTemplateResourceType = &v2.ResourceType{
+	Id:          "company_permission_template",
+	DisplayName: "Company Permission Template",
+	Traits:      []v2.ResourceType_Trait{v2.ResourceType_TRAIT_ROLE},
+}
+
+var vendorResourceType = &v2.ResourceType{
+	Id:          "vendor",
+	DisplayName: "Vendor",
+	Traits:      []v2.ResourceType_Trait{v2.ResourceType_TRAIT_GROUP},
+}
+
+var distributionGroupResourceType = &v2.ResourceType{
+	Id:          "distribution_group",
+	DisplayName: "Distribution Group",
+	Traits:      []v2.ResourceType_Trait{v2.ResourceType_TRAIT_GROUP},
+}
+
+var projectRoleResourceType = &v2.ResourceType{
+	Id:          "project_role",
+	DisplayName: "Project Role",
+	Traits:      []v2.ResourceType_Trait{v2.ResourceType_TRAIT_ROLE},
+}
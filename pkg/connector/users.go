@@ -2,8 +2,11 @@ package connector
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"slices"
 	"strconv"
+	"strings"
 
 	"github.com/conductorone/baton-procore/pkg/client"
 	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
@@ -11,27 +14,72 @@ import (
 	"github.com/conductorone/baton-sdk/pkg/connectorbuilder"
 	"github.com/conductorone/baton-sdk/pkg/pagination"
 	resourceSdk "github.com/conductorone/baton-sdk/pkg/types/resource"
+	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+const (
+	// contactIdPrefix keeps contact resource ids apart from user ids, as they come from different id spaces.
+	contactIdPrefix = "contact-"
+	// contactsPageToken prefixes the List page tokens once the company users are exhausted.
+	contactsPageToken = "contacts:"
 )
 
 type userBuilder struct {
-	client *client.Client
+	client        *client.Client
+	companyUsers  *companyUsers
+	companyFilter *companyFilter
+	// removeFromProjects makes Delete remove the user from every project before deactivating them.
+	removeFromProjects bool
+}
+
+// companyUserRecord is the user record of one of the companies the user belongs to.
+type companyUserRecord struct {
+	companyId string
+	user      *client.User
 }
 
 func (o *userBuilder) ResourceType(ctx context.Context) *v2.ResourceType {
 	return userResourceType
 }
 
-func userResource(user client.User) (*v2.Resource, error) {
+// userResource builds the resource of a Procore login from the company user records of every company
+// the user belongs to. The login is enabled while it is active in any of them.
+func userResource(memberships []companyUserRecord) (*v2.Resource, error) {
+	user := *memberships[0].user
+	companies := make([]any, 0, len(memberships))
+	activeCompanies := make([]any, 0, len(memberships))
+	active := false
+	for _, membership := range memberships {
+		companies = append(companies, membership.companyId)
+		if membership.user.IsActive {
+			activeCompanies = append(activeCompanies, membership.companyId)
+			active = true
+		}
+		user.IsEmployee = user.IsEmployee || membership.user.IsEmployee
+		if user.EmployeeId == "" {
+			user.EmployeeId = membership.user.EmployeeId
+		}
+		if !membership.user.CreatedAt.IsZero() && (user.CreatedAt.IsZero() || membership.user.CreatedAt.Before(user.CreatedAt)) {
+			user.CreatedAt = membership.user.CreatedAt
+		}
+		if membership.user.LastLoginAt.After(user.LastLoginAt) {
+			user.LastLoginAt = membership.user.LastLoginAt
+		}
+	}
 	profile := map[string]any{
 		"email":      user.EmailAddress,
 		"isEmployee": user.IsEmployee,
 		// contact, previously known as reference person, is an individual without a procore account
 		// https://support.procore.com/faq/what-is-a-contact-in-procore-and-which-project-tools-support-the-concept
-		"contact": false,
+		"contact":            false,
+		"company_ids":        companies,
+		"active_company_ids": activeCompanies,
 	}
 
 	status := v2.UserTrait_Status_STATUS_ENABLED
-	if !user.IsActive {
+	if !active {
 		status = v2.UserTrait_Status_STATUS_DISABLED
 	}
 
@@ -57,13 +105,58 @@ func userResource(user client.User) (*v2.Resource, error) {
 	)
 }
 
+// contactResource builds a user resource for a contact, an individual listed in the company
+// directory without a Procore login.
+func contactResource(contact client.Contact) (*v2.Resource, error) {
+	profile := map[string]any{
+		"email":      contact.ContactInfo.Email,
+		"isEmployee": contact.IsEmployee,
+		"contact":    true,
+	}
+	if contact.UserId != nil {
+		// the user resource of the same individual, when they also have a login
+		profile["user_id"] = strconv.FormatInt(*contact.UserId, 10)
+	}
+
+	options := []resourceSdk.UserTraitOption{
+		resourceSdk.WithUserProfile(profile),
+		resourceSdk.WithStatus(v2.UserTrait_Status_STATUS_DISABLED),
+		resourceSdk.WithAccountType(v2.UserTrait_ACCOUNT_TYPE_HUMAN),
+	}
+	if contact.ContactInfo.Email != "" {
+		options = append(options, resourceSdk.WithEmail(contact.ContactInfo.Email, true))
+	}
+	if contact.EmployeeId != "" {
+		options = append(options, resourceSdk.WithEmployeeID(contact.EmployeeId))
+	}
+
+	return resourceSdk.NewUserResource(
+		strings.TrimSpace(contact.FirstName+" "+contact.LastName),
+		userResourceType,
+		fmt.Sprintf("%s%d", contactIdPrefix, contact.Id),
+		options,
+	)
+}
+
 // List returns all the users from the database as resource objects.
 // Users include a UserTrait because they are the 'shape' of a standard user.
+// A user who belongs to several companies is only listed under the first of them, so each
+// Procore login has a single resource built from all of their memberships; the memberships
+// also show up as company grants.
+// The company contacts are listed once the company users are exhausted.
 func (o *userBuilder) List(ctx context.Context, parentResourceID *v2.ResourceId, pToken *pagination.Token) ([]*v2.Resource, string, annotations.Annotations, error) {
 	if parentResourceID == nil {
 		return nil, "", nil, nil
 	}
 
+	if strings.HasPrefix(pToken.Token, contactsPageToken) {
+		page, err := strconv.Atoi(strings.TrimPrefix(pToken.Token, contactsPageToken))
+		if err != nil {
+			return nil, "", nil, fmt.Errorf("baton-procore: failed to parse page token: %w", err)
+		}
+		return o.listContacts(ctx, parentResourceID, page)
+	}
+
 	page := 1
 	var err error
 	if pToken.Token != "" {
@@ -82,20 +175,51 @@ func (o *userBuilder) List(ctx context.Context, parentResourceID *v2.ResourceId,
 
 	rv := make([]*v2.Resource, 0, len(users))
 	for _, user := range users {
-		resource, err := userResource(user)
+		memberships, err := o.companyUsers.companiesWith(ctx, parentResourceID.Resource, &user)
+		if err != nil {
+			return nil, "", nil, err
+		}
+		if memberships[0].companyId != parentResourceID.Resource {
+			continue
+		}
+		resource, err := userResource(memberships)
 		if err != nil {
 			return nil, "", nil, fmt.Errorf("baton-procore: error converting user to resource: %w", err)
 		}
 		rv = append(rv, resource)
 	}
 
-	var nextPage string
+	nextPage := contactsPageToken + "1"
 	if client.HasNextPage(res) {
 		nextPage = strconv.Itoa(page + 1)
 	}
 	return rv, nextPage, annotations, nil
 }
 
+func (o *userBuilder) listContacts(ctx context.Context, parentResourceID *v2.ResourceId, page int) ([]*v2.Resource, string, annotations.Annotations, error) {
+	var annotations annotations.Annotations
+	contacts, res, rateLimitDesc, err := o.client.GetCompanyContacts(ctx, parentResourceID.Resource, page)
+	if err != nil {
+		return nil, "", nil, fmt.Errorf("baton-procore: error getting contacts: %w", err)
+	}
+	annotations = *annotations.WithRateLimiting(rateLimitDesc)
+
+	rv := make([]*v2.Resource, 0, len(contacts))
+	for _, contact := range contacts {
+		resource, err := contactResource(contact)
+		if err != nil {
+			return nil, "", nil, fmt.Errorf("baton-procore: error converting contact to resource: %w", err)
+		}
+		rv = append(rv, resource)
+	}
+
+	var nextPage string
+	if client.HasNextPage(res) {
+		nextPage = contactsPageToken + strconv.Itoa(page+1)
+	}
+	return rv, nextPage, annotations, nil
+}
+
 // Entitlements always returns an empty slice for users.
 func (o *userBuilder) Entitlements(_ context.Context, resource *v2.Resource, _ *pagination.Token) ([]*v2.Entitlement, string, annotations.Annotations, error) {
 	return nil, "", nil, nil
@@ -121,7 +245,17 @@ func (o *userBuilder) CreateAccount(ctx context.Context, accountInfo *v2.Account
 	annotations.Annotations,
 	error,
 ) {
+	l := ctxzap.Extract(ctx)
 	pMap := accountInfo.Profile.AsMap()
+	companies, err := listAllCompanies(ctx, o.client, o.companyFilter)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	err = validateAccountProfile(accountCreationSchema(companies), pMap)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
 	companyId, ok := pMap["companyId"].(string)
 	if !ok {
 		return nil, nil, nil, fmt.Errorf("baton-procore: companyId not found in parent resource ID")
@@ -134,38 +268,274 @@ func (o *userBuilder) CreateAccount(ctx context.Context, accountInfo *v2.Account
 	if !ok {
 		return nil, nil, nil, fmt.Errorf("baton-procore: lastName not found in profile")
 	}
+	if !slices.ContainsFunc(companies, func(company client.Company) bool {
+		return strconv.FormatInt(company.Id, 10) == companyId
+	}) {
+		return nil, nil, nil, fmt.Errorf("baton-procore: company %s is not visible to the service account or is excluded by the configuration", companyId)
+	}
 	firstName, _ := pMap["firstName"].(string)
 	city, _ := pMap["city"].(string)
 	address, _ := pMap["address"].(string)
 	jobTitle, _ := pMap["jobTitle"].(string)
 	isEmployee, _ := pMap["isEmployee"].(bool)
 	isActive, _ := pMap["isActive"].(bool)
+	companyPermissionTemplateId, err := optionalIntProfileValue(pMap, "companyPermissionTemplateId")
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	vendorId, err := optionalIntProfileValue(pMap, "vendorId")
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	projects, err := parseAccountProjects(pMap["projects"])
+	if err != nil {
+		return nil, nil, nil, err
+	}
 
-	err := o.client.CreateCompanyUser(ctx, companyId, client.CreateUserBody{
+	user, err := o.client.CreateCompanyUser(ctx, companyId, client.CreateUserBody{
 		User: client.UserBody{
-			EmailAddress: email,
-			LastName:     lastName,
-			FirstName:    firstName,
-			City:         city,
-			Address:      address,
-			JobTitle:     jobTitle,
-			IsEmployee:   isEmployee,
-			IsActive:     isActive,
+			EmailAddress:                email,
+			LastName:                    lastName,
+			FirstName:                   firstName,
+			City:                        city,
+			Address:                     address,
+			JobTitle:                    jobTitle,
+			IsEmployee:                  isEmployee,
+			IsActive:                    isActive,
+			CompanyPermissionTemplateId: companyPermissionTemplateId,
+			VendorId:                    vendorId,
 		},
 	})
+	if errors.Is(err, client.ErrUserAlreadyExists) {
+		// return the existing account, so the requester can still be linked to it
+		user, err = o.client.FindCompanyUserByEmail(ctx, companyId, email)
+		if err == nil && user == nil {
+			err = fmt.Errorf("baton-procore: user with email %s already exists but was not found in company %s", email, companyId)
+		}
+	}
 	if err != nil {
 		return nil, nil, nil, fmt.Errorf("baton-procore: failed to create account: %w", err)
 	}
 
-	return &v2.CreateAccountResponse_ActionRequiredResult{}, nil, nil, nil
+	// the account exists from here on, so a failed project is reported alongside the created account
+	// instead of failing the request, and the remaining projects are still attempted
+	var annos annotations.Annotations
+	for _, project := range projects {
+		err = o.addToAccountProject(ctx, companyId, user.Id, project)
+		if err != nil {
+			l.Warn("baton-procore: account was created but adding it to a project failed",
+				zap.Int("user_id", user.Id),
+				zap.String("company_id", companyId),
+				zap.String("project_id", project.projectId),
+				zap.Error(err),
+			)
+			annos.Append(&structpb.Struct{
+				Fields: map[string]*structpb.Value{
+					"failed_project_id": structpb.NewStringValue(project.projectId),
+					"error":             structpb.NewStringValue(err.Error()),
+				},
+			})
+		}
+	}
+
+	memberships, err := o.companyUsers.readMemberships(ctx, user.Id, &companyUserRecord{companyId: companyId, user: user})
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	resource, err := userResource(memberships)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("baton-procore: error converting user to resource: %w", err)
+	}
+
+	return &v2.CreateAccountResponse_SuccessResult{
+		Resource:              resource,
+		IsCreateAccountResult: true,
+	}, nil, annos, nil
+}
+
+// validateAccountProfile checks the account profile against the account creation schema, so
+// a malformed request fails before anything is created in Procore.
+func validateAccountProfile(schema *v2.ConnectorAccountCreationSchema, pMap map[string]any) error {
+	for name, field := range schema.GetFieldMap() {
+		value, ok := pMap[name]
+		if !ok || value == nil {
+			if field.GetRequired() {
+				return fmt.Errorf("baton-procore: %s is required", name)
+			}
+			continue
+		}
+
+		switch field.GetField().(type) {
+		case *v2.ConnectorAccountCreationSchema_Field_StringField:
+			s, ok := value.(string)
+			if !ok {
+				return fmt.Errorf("baton-procore: %s must be a string", name)
+			}
+			if field.GetRequired() && s == "" {
+				return fmt.Errorf("baton-procore: %s is required", name)
+			}
+		case *v2.ConnectorAccountCreationSchema_Field_BoolField:
+			if _, ok := value.(bool); !ok {
+				return fmt.Errorf("baton-procore: %s must be a boolean", name)
+			}
+		case *v2.ConnectorAccountCreationSchema_Field_StringListField:
+			entries, ok := value.([]any)
+			if !ok {
+				return fmt.Errorf("baton-procore: %s must be a list of strings", name)
+			}
+			for _, entry := range entries {
+				if _, ok := entry.(string); !ok {
+					return fmt.Errorf("baton-procore: %s must be a list of strings", name)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// accountProject is a project requested during account creation, with an optional project
+// permission template.
+type accountProject struct {
+	projectId  string
+	templateId *int
+}
+
+// parseAccountProjects parses the "projects" account field, a list of "projectId" or
+// "projectId:templateId" entries.
+func parseAccountProjects(value any) ([]accountProject, error) {
+	if value == nil {
+		return nil, nil
+	}
+	entries, ok := value.([]any)
+	if !ok {
+		return nil, fmt.Errorf("baton-procore: projects must be a list of project ids")
+	}
+
+	rv := make([]accountProject, 0, len(entries))
+	for _, entry := range entries {
+		raw, ok := entry.(string)
+		if !ok {
+			return nil, fmt.Errorf("baton-procore: invalid projects entry %v", entry)
+		}
+		projectId, rawTemplateId, hasTemplate := strings.Cut(strings.TrimSpace(raw), ":")
+		if _, err := strconv.Atoi(projectId); err != nil {
+			return nil, fmt.Errorf("baton-procore: invalid project id in projects entry %q: %w", raw, err)
+		}
+		project := accountProject{projectId: projectId}
+		if hasTemplate {
+			templateId, err := strconv.Atoi(rawTemplateId)
+			if err != nil {
+				return nil, fmt.Errorf("baton-procore: invalid project permission template id in projects entry %q: %w", raw, err)
+			}
+			project.templateId = &templateId
+		}
+		rv = append(rv, project)
+	}
+	return rv, nil
+}
+
+// optionalIntProfileValue parses an optional numeric id sent as a string account field.
+func optionalIntProfileValue(pMap map[string]any, key string) (*int, error) {
+	raw, _ := pMap[key].(string)
+	if raw == "" {
+		return nil, nil
+	}
+	value, err := strconv.Atoi(raw)
+	if err != nil {
+		return nil, fmt.Errorf("baton-procore: invalid %s: %w", key, err)
+	}
+	return &value, nil
+}
+
+func (o *userBuilder) addToAccountProject(ctx context.Context, companyId string, userId int, project accountProject) error {
+	if project.templateId == nil {
+		return o.client.AddUserToProject(ctx, companyId, project.projectId, userId, nil)
+	}
+	_, err := addUserToProjectWithTemplate(ctx, o.companyUsers, companyId, project.projectId, userId, *project.templateId)
+	return err
 }
 
+// Delete deactivates the user in every company they belong to, as Procore does not allow deleting
+// company users. Users that are already inactive are left untouched.
 func (o *userBuilder) Delete(ctx context.Context, resourceId *v2.ResourceId) (annotations.Annotations, error) {
-	return nil, fmt.Errorf("baton-procore: delete operation is not supported in the procore API yet ")
+	l := ctxzap.Extract(ctx)
+	if strings.HasPrefix(resourceId.Resource, contactIdPrefix) {
+		return nil, fmt.Errorf("baton-procore: contacts have no Procore login and cannot be deleted")
+	}
+	userId, err := strconv.Atoi(resourceId.Resource)
+	if err != nil {
+		return nil, fmt.Errorf("baton-procore: failed to parse user id: %w", err)
+	}
+
+	// the records are read past the HTTP cache, as Delete decides on their active flag
+	memberships, err := o.companyUsers.readMemberships(ctx, userId, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, membership := range memberships {
+		if o.removeFromProjects {
+			err = o.removeFromAllProjects(ctx, membership.companyId, *membership.user)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if !membership.user.IsActive {
+			l.Debug("baton-procore: user is already inactive", zap.Int("user_id", userId), zap.String("company_id", membership.companyId))
+			continue
+		}
+
+		_, err = o.client.SetCompanyUserActive(ctx, membership.companyId, userId, false)
+		if err != nil {
+			return nil, fmt.Errorf("baton-procore: error deactivating user in company %s: %w", membership.companyId, err)
+		}
+	}
+
+	return nil, nil
+}
+
+// removeFromAllProjects removes the user from every project of the company they are on, by their
+// project user id on each project.
+func (o *userBuilder) removeFromAllProjects(ctx context.Context, companyId string, user client.User) error {
+	// collect every page first, as removing the user shrinks the list being paged through
+	var projectIds []string
+	page := 1
+	for {
+		projects, res, _, err := o.client.Uncached().GetCompanyUserProjects(ctx, companyId, user.Id, page)
+		if err != nil {
+			return fmt.Errorf("baton-procore: error getting the projects of the user: %w", err)
+		}
+		for _, project := range projects {
+			projectIds = append(projectIds, strconv.Itoa(project.Id))
+		}
+		if !client.HasNextPage(res) {
+			break
+		}
+		page++
+	}
+
+	for _, projectId := range projectIds {
+		projectUser, err := o.companyUsers.findOnProject(ctx, companyId, projectId, user)
+		if err != nil {
+			return err
+		}
+		if projectUser == nil {
+			continue
+		}
+		err = o.client.RemoveUserFromProject(ctx, companyId, projectId, projectUser.Id)
+		if err != nil {
+			return fmt.Errorf("baton-procore: error removing user from project %s: %w", projectId, err)
+		}
+	}
+	return nil
 }
 
-func newUserBuilder(client *client.Client) *userBuilder {
+func newUserBuilder(client *client.Client, companyUsers *companyUsers, companyFilter *companyFilter, removeFromProjects bool) *userBuilder {
 	return &userBuilder{
-		client: client,
+		client:             client,
+		companyUsers:       companyUsers,
+		companyFilter:      companyFilter,
+		removeFromProjects: removeFromProjects,
 	}
 }
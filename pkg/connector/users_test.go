@@ -0,0 +1,233 @@
+package connector
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/conductorone/baton-procore/pkg/client"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	resourceSdk "github.com/conductorone/baton-sdk/pkg/types/resource"
+	"github.com/conductorone/baton-sdk/pkg/uhttp"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestDeleteDeactivatesAndRemovesFromProjects(t *testing.T) {
+	ctx := context.Background()
+
+	var mu sync.Mutex
+	active := true
+	projectListings := 0
+	var removed []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1.0/companies", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, []client.Company{{Id: 1, Name: "Acme"}})
+	})
+	mux.HandleFunc("GET /v1.3/companies/1/users/7", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		writeJSON(w, client.User{Id: 7, EmailAddress: "ada@example.com", IsActive: active})
+	})
+	mux.HandleFunc("PATCH /v1.3/companies/1/users/7", func(w http.ResponseWriter, r *http.Request) {
+		var body client.UpdateUserBody
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		active = *body.User.IsActive
+		writeJSON(w, client.User{Id: 7, IsActive: active})
+	})
+	mux.HandleFunc("GET /v1.0/companies/1/users/7/projects", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		projectListings++
+		writeJSON(w, []client.Project{{Id: 100}, {Id: 200}})
+	})
+	// the user has a different project user id on each project
+	mux.HandleFunc("GET /v1.0/projects/{projectId}/users", func(w http.ResponseWriter, r *http.Request) {
+		projectUserId, _ := strconv.Atoi(r.PathValue("projectId"))
+		writeJSON(w, []client.User{{Id: projectUserId + 7, EmailAddress: "ada@example.com"}})
+	})
+	mux.HandleFunc("DELETE /v1.0/projects/{projectId}/users/{projectUserId}/actions/remove", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		removed = append(removed, r.PathValue("projectId")+"/"+r.PathValue("projectUserId"))
+		w.WriteHeader(http.StatusNoContent)
+	})
+	c := newTestClient(t, mux)
+	companyFilter := newCompanyFilter(nil, nil)
+	builder := newUserBuilder(c, newCompanyUsers(c, companyFilter), companyFilter, true)
+
+	// the sync cached the user while they were inactive, then they were reactivated
+	mu.Lock()
+	active = false
+	mu.Unlock()
+	if _, _, err := c.GetCompanyUser(ctx, "1", 7); err != nil {
+		t.Fatalf("reading user: %v", err)
+	}
+	mu.Lock()
+	active = true
+	mu.Unlock()
+
+	_, err := builder.Delete(ctx, &v2.ResourceId{ResourceType: userResourceType.Id, Resource: "7"})
+	if err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if active {
+		t.Fatal("the user is still active after delete")
+	}
+	if projectListings != 1 {
+		t.Fatalf("the projects of the user were listed %d times, want 1", projectListings)
+	}
+	if len(removed) != 2 || removed[0] != "100/107" || removed[1] != "200/207" {
+		t.Fatalf("removed project users %v, want [100/107 200/207]", removed)
+	}
+}
+
+func TestUserResourceFromAllMemberships(t *testing.T) {
+	inactive := &client.User{Id: 7, Name: "Ada", IsActive: false, IsEmployee: true}
+	active := &client.User{Id: 7, Name: "Ada", IsActive: true, IsEmployee: true, EmployeeId: "E-7"}
+
+	resource, err := userResource([]companyUserRecord{
+		{companyId: "1", user: inactive},
+		{companyId: "2", user: active},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	trait, err := resourceSdk.GetUserTrait(resource)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if trait.GetStatus().GetStatus() != v2.UserTrait_Status_STATUS_ENABLED {
+		t.Fatalf("status = %v, want enabled as the user is active in company 2", trait.GetStatus().GetStatus())
+	}
+	if trait.GetEmployeeIds()[0] != "E-7" {
+		t.Fatalf("employee ids = %v, want the one from company 2", trait.GetEmployeeIds())
+	}
+	companies := trait.GetProfile().GetFields()["company_ids"].GetListValue().GetValues()
+	if len(companies) != 2 || companies[0].GetStringValue() != "1" || companies[1].GetStringValue() != "2" {
+		t.Fatalf("company_ids = %v, want [1 2]", companies)
+	}
+}
+
+func TestCreateAccountReturnsTheAccountWhenAProjectFails(t *testing.T) {
+	ctx := context.Background()
+	created := client.User{Id: 7, Name: "Ada Lovelace", EmailAddress: "ada@example.com", IsActive: true, IsEmployee: true}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1.0/companies", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, []client.Company{{Id: 1, Name: "Acme"}})
+	})
+	mux.HandleFunc("POST /v1.3/companies/1/users", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, created)
+	})
+	mux.HandleFunc("GET /v1.3/companies/1/users", func(w http.ResponseWriter, r *http.Request) {
+		t.Error("the company directory was listed to build a single account")
+		writeJSON(w, []client.User{created})
+	})
+	mux.HandleFunc("GET /v1.3/companies/1/users/7", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, created)
+	})
+	mux.HandleFunc("POST /v1.0/projects/100/users/7/actions/add", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"errors":"Project Directory is disabled"}`, http.StatusForbidden)
+	})
+	mux.HandleFunc("POST /v1.0/projects/200/users/7/actions/add", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, client.User{Id: 70})
+	})
+	c := newTestClient(t, mux)
+	companyFilter := newCompanyFilter(nil, nil)
+	builder := newUserBuilder(c, newCompanyUsers(c, companyFilter), companyFilter, false)
+
+	profile, err := structpb.NewStruct(map[string]any{
+		"companyId": "1",
+		"email":     "ada@example.com",
+		"lastName":  "Lovelace",
+		"projects":  []any{"100", "200"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, _, annos, err := builder.CreateAccount(ctx, &v2.AccountInfo{Profile: profile}, &v2.CredentialOptions{})
+	if err != nil {
+		t.Fatalf("create account: %v", err)
+	}
+	success, ok := res.(*v2.CreateAccountResponse_SuccessResult)
+	if !ok || success.GetResource().GetId().GetResource() != "7" || !success.GetIsCreateAccountResult() {
+		t.Fatalf("result = %v, want the created account 7", res)
+	}
+
+	failed := &structpb.Struct{}
+	ok, err = annos.Pick(failed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ok || len(annos) != 1 || failed.GetFields()["failed_project_id"].GetStringValue() != "100" {
+		t.Fatalf("annotations = %v, want one naming the failed project 100", annos)
+	}
+}
+
+func TestUserListAfterLeavingTheFirstCompany(t *testing.T) {
+	ctx := context.Background()
+	ada := client.User{Id: 7, Name: "Ada", EmailAddress: "ada@example.com", IsActive: true, IsEmployee: true}
+
+	var mu sync.Mutex
+	inFirstCompany := true
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1.0/companies", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, []client.Company{{Id: 1, Name: "Acme"}, {Id: 2, Name: "Globex"}})
+	})
+	mux.HandleFunc("GET /v1.3/companies/1/users", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if !inFirstCompany {
+			writeJSON(w, []client.User{})
+			return
+		}
+		writeJSON(w, []client.User{ada})
+	})
+	mux.HandleFunc("GET /v1.3/companies/2/users", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, []client.User{ada})
+	})
+	c := newTestClient(t, mux)
+	companyFilter := newCompanyFilter(nil, nil)
+	companyUsers := newCompanyUsers(c, companyFilter)
+	builder := newUserBuilder(c, companyUsers, companyFilter, false)
+	listUsers := func(companyId string) []*v2.Resource {
+		t.Helper()
+		parent := &v2.ResourceId{ResourceType: companyResourceType.Id, Resource: companyId}
+		resources, _, _, err := builder.List(ctx, parent, &pagination.Token{})
+		if err != nil {
+			t.Fatalf("listing users of company %s: %v", companyId, err)
+		}
+		return resources
+	}
+
+	// the first sync lists Ada under the first company she belongs to
+	if got := len(listUsers("1")) + len(listUsers("2")); got != 1 {
+		t.Fatalf("the first sync listed %d user resources, want 1", got)
+	}
+
+	// she leaves the first company, the SDK clears the HTTP cache and the next sync starts
+	mu.Lock()
+	inFirstCompany = false
+	mu.Unlock()
+	if err := uhttp.ClearCaches(ctx); err != nil {
+		t.Fatal(err)
+	}
+	companyUsers.reset()
+
+	if got := listUsers("1"); len(got) != 0 {
+		t.Fatalf("company 1 listed %d users, want none", len(got))
+	}
+	got := listUsers("2")
+	if len(got) != 1 || got[0].GetId().GetResource() != "7" {
+		t.Fatalf("company 2 listed %v, want Ada as she is still a member", got)
+	}
+}
@@ -0,0 +1,144 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/conductorone/baton-procore/pkg/client"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	"github.com/conductorone/baton-sdk/pkg/types/entitlement"
+	"github.com/conductorone/baton-sdk/pkg/types/grant"
+	resourceSdk "github.com/conductorone/baton-sdk/pkg/types/resource"
+)
+
+const vendorEmployee = "employee"
+
+// vendorBuilder syncs the vendors (directory companies) of each Procore company.
+type vendorBuilder struct {
+	client *client.Client
+}
+
+func (o *vendorBuilder) ResourceType(ctx context.Context) *v2.ResourceType {
+	return vendorResourceType
+}
+
+func vendorResource(companyId string, vendor client.Vendor, parentResourceID *v2.ResourceId) (*v2.Resource, error) {
+	profile := map[string]any{
+		"company_id":       companyId,
+		"abbreviated_name": vendor.Abbreviation,
+		"is_active":        vendor.IsActive,
+	}
+	return resourceSdk.NewGroupResource(
+		vendor.Name,
+		vendorResourceType,
+		vendor.Id,
+		[]resourceSdk.GroupTraitOption{
+			resourceSdk.WithGroupProfile(profile),
+		},
+		resourceSdk.WithParentResourceID(parentResourceID),
+	)
+}
+
+func (o *vendorBuilder) List(ctx context.Context, parentResourceID *v2.ResourceId, pToken *pagination.Token) ([]*v2.Resource, string, annotations.Annotations, error) {
+	if parentResourceID == nil {
+		return nil, "", nil, nil
+	}
+
+	page := 1
+	var err error
+	if pToken.Token != "" {
+		page, err = strconv.Atoi(pToken.Token)
+		if err != nil {
+			return nil, "", nil, fmt.Errorf("baton-procore: failed to parse page token: %w", err)
+		}
+	}
+
+	var annotations annotations.Annotations
+	vendors, res, rateLimitDesc, err := o.client.GetCompanyVendors(ctx, parentResourceID.Resource, page)
+	if err != nil {
+		return nil, "", nil, fmt.Errorf("baton-procore: error getting vendors: %w", err)
+	}
+	annotations = *annotations.WithRateLimiting(rateLimitDesc)
+
+	rv := make([]*v2.Resource, 0, len(vendors))
+	for _, vendor := range vendors {
+		resource, err := vendorResource(parentResourceID.Resource, vendor, parentResourceID)
+		if err != nil {
+			return nil, "", nil, fmt.Errorf("baton-procore: error converting vendor to resource: %w", err)
+		}
+		rv = append(rv, resource)
+	}
+
+	var nextPage string
+	if client.HasNextPage(res) {
+		nextPage = strconv.Itoa(page + 1)
+	}
+	return rv, nextPage, annotations, nil
+}
+
+func (o *vendorBuilder) Entitlements(_ context.Context, resource *v2.Resource, _ *pagination.Token) ([]*v2.Entitlement, string, annotations.Annotations, error) {
+	return []*v2.Entitlement{
+		entitlement.NewAssignmentEntitlement(
+			resource,
+			vendorEmployee,
+			entitlement.WithGrantableTo(userResourceType),
+			entitlement.WithDescription(fmt.Sprintf("Employee of %s vendor", resource.DisplayName)),
+			entitlement.WithDisplayName(fmt.Sprintf("Employee of %s vendor", resource.DisplayName)),
+		),
+	}, "", nil, nil
+}
+
+// Grants pages through the company users and emits a grant for every user affiliated with the vendor.
+func (o *vendorBuilder) Grants(ctx context.Context, resource *v2.Resource, pToken *pagination.Token) ([]*v2.Grant, string, annotations.Annotations, error) {
+	page := 1
+	var err error
+	if pToken.Token != "" {
+		page, err = strconv.Atoi(pToken.Token)
+		if err != nil {
+			return nil, "", nil, fmt.Errorf("baton-procore: failed to parse page token: %w", err)
+		}
+	}
+
+	companyId, err := getCompanyId(resource)
+	if err != nil {
+		return nil, "", nil, fmt.Errorf("baton-procore: error getting company id from vendor resource: %w", err)
+	}
+
+	var annotations annotations.Annotations
+	users, res, rateLimitDesc, err := o.client.GetCompanyUsers(ctx, companyId, page)
+	if err != nil {
+		return nil, "", nil, fmt.Errorf("baton-procore: error getting users: %w", err)
+	}
+	annotations = *annotations.WithRateLimiting(rateLimitDesc)
+
+	rv := make([]*v2.Grant, 0)
+	for _, user := range users {
+		if strconv.Itoa(user.Vendor.Id) != resource.Id.Resource {
+			continue
+		}
+		principalID, err := resourceSdk.NewResourceID(userResourceType, user.Id)
+		if err != nil {
+			return nil, "", nil, fmt.Errorf("baton-procore: failed to create user resource ID: %w", err)
+		}
+		rv = append(rv, grant.NewGrant(
+			resource,
+			vendorEmployee,
+			principalID,
+		))
+	}
+
+	var nextPage string
+	if client.HasNextPage(res) {
+		nextPage = strconv.Itoa(page + 1)
+	}
+	return rv, nextPage, annotations, nil
+}
+
+func newVendorBuilder(client *client.Client) *vendorBuilder {
+	return &vendorBuilder{
+		client: client,
+	}
+}